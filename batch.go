@@ -0,0 +1,399 @@
+package influxql
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Default settings of BatchWriter.
+const (
+	DefaultBatchSize     = 5000
+	DefaultFlushInterval = time.Second
+	DefaultMaxRetries    = 3
+	DefaultRetryBackoff  = 100 * time.Millisecond
+	DefaultMaxBackoff    = 10 * time.Second
+	DefaultMaxPending    = 20 * DefaultBatchSize
+)
+
+// ErrWriterClosed is returned when points are written to a closed
+// BatchWriter.
+var ErrWriterClosed = errors.New("writer is closed")
+
+// DroppedPointsError reports points BatchWriter gave up on, they are not
+// written.
+type DroppedPointsError struct {
+	Points int
+	// Err is the error of the last attempt to send the points.
+	Err error
+}
+
+func (e *DroppedPointsError) Error() string {
+	return fmt.Sprintf("%d points dropped: %v", e.Points, e.Err)
+}
+
+// Unwrap returns the error of the last attempt to send the points.
+func (e *DroppedPointsError) Unwrap() error {
+	return e.Err
+}
+
+// BatchWriter buffers points and writes them in gzip-compressed batches, a
+// batch is sent when it reaches the batch size or when the flush interval
+// elapses. Failed requests are retried when the server responds with a 5xx
+// status or does not respond at all, like when the connection is refused or
+// times out. A batch that still cannot be sent, or whose flush is canceled,
+// is kept and sent again by the next flush before the newer points, up to
+// MaxPending points. Batches rejected by the server, the oldest batches kept
+// beyond MaxPending and the batches left unsent by Close are dropped and
+// reported to OnError.
+//
+// It is safe for concurrent use. It is configured with chained calls before
+// the first point is written, and must be closed to flush the last batch and
+// stop the background flushes.
+type BatchWriter struct {
+	writer *Writer
+
+	size       int
+	interval   time.Duration
+	maxRetries int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	compress   bool
+	maxPending int
+	onError    func(error)
+
+	mu     sync.Mutex
+	buf    []byte
+	count  int
+	closed bool
+	// unsent holds the batches kept after failed flushes, in order.
+	unsent []*batch
+
+	// sendMu keeps batches in order and provides backpressure.
+	sendMu sync.Mutex
+
+	start sync.Once
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+type batch struct {
+	body   []byte
+	points int
+}
+
+// Batch creates a batching writer on top of w.
+func (w *Writer) Batch() *BatchWriter {
+	return &BatchWriter{
+		writer:     w,
+		size:       DefaultBatchSize,
+		interval:   DefaultFlushInterval,
+		maxRetries: DefaultMaxRetries,
+		backoff:    DefaultRetryBackoff,
+		maxBackoff: DefaultMaxBackoff,
+		compress:   true,
+		maxPending: DefaultMaxPending,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Size sets the number of points that triggers a flush.
+func (b *BatchWriter) Size(points int) *BatchWriter {
+	b.size = points
+	return b
+}
+
+// Interval sets the time after which buffered points are flushed, zero
+// disables the background flushes.
+func (b *BatchWriter) Interval(interval time.Duration) *BatchWriter {
+	b.interval = interval
+	return b
+}
+
+// Retries sets the number of times a failed batch is sent again.
+func (b *BatchWriter) Retries(retries int) *BatchWriter {
+	b.maxRetries = retries
+	return b
+}
+
+// Backoff sets the delay before the first retry and its upper bound, the
+// delay doubles with every retry and is randomized by up to a half.
+func (b *BatchWriter) Backoff(initial time.Duration, max time.Duration) *BatchWriter {
+	b.backoff = initial
+	b.maxBackoff = max
+	return b
+}
+
+// Gzip enables or disables the compression of request bodies, it is enabled
+// by default.
+func (b *BatchWriter) Gzip(enabled bool) *BatchWriter {
+	b.compress = enabled
+	return b
+}
+
+// MaxPending sets the maximum number of points kept for the next flush after
+// failed flushes, the oldest batches are dropped beyond it. It should be at
+// least the batch size. Zero or less keeps any number of points.
+func (b *BatchWriter) MaxPending(points int) *BatchWriter {
+	b.maxPending = points
+	return b
+}
+
+// OnError sets the function called with the errors of background flushes
+// and with the errors of dropped batches, they are discarded otherwise. It
+// must not call the methods of the writer.
+func (b *BatchWriter) OnError(fn func(error)) *BatchWriter {
+	b.onError = fn
+	return b
+}
+
+// Write buffers the points. When the batch is full it is flushed with ctx
+// before Write returns, and the error of the flush is returned, see Flush.
+// Invalid points are rejected and none of the given points is buffered.
+func (b *BatchWriter) Write(ctx context.Context, points ...*Point) error {
+	var (
+		lines []byte
+		err   error
+	)
+	for i, p := range points {
+		lines, err = p.appendLine(lines, b.writer.precision)
+		if err != nil {
+			return fmt.Errorf("Point %d: %v", i+1, err)
+		}
+		lines = append(lines, '\n')
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrWriterClosed
+	}
+	b.start.Do(b.run)
+	b.buf = append(b.buf, lines...)
+	b.count += len(points)
+	full := b.count >= b.size
+	b.mu.Unlock()
+
+	if full {
+		return b.Flush(ctx)
+	}
+	return nil
+}
+
+// Flush sends the batches kept by previous flushes and the buffered points.
+// When a batch cannot be sent because ctx is done or the server is not
+// available, it is kept with the following ones for the next flush and the
+// error is returned, the oldest batches beyond MaxPending points are dropped
+// and reported to OnError. A batch rejected by the server is dropped, the error is
+// reported to OnError and returned, and the following batches are sent.
+func (b *BatchWriter) Flush(ctx context.Context) error {
+	return b.flush(ctx, false)
+}
+
+// Close flushes the buffered points and stops the background flushes,
+// points written afterwards are rejected with ErrWriterClosed. Batches that
+// cannot be sent are dropped and a DroppedPointsError is returned. Use Flush
+// first to bound the time spent with a context.
+func (b *BatchWriter) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.start.Do(func() { close(b.done) })
+	b.mu.Unlock()
+
+	close(b.stop)
+	<-b.done
+
+	return b.flush(context.Background(), true)
+}
+
+// flush sends the batches in order. When final is set batches are never
+// kept, they are dropped instead.
+func (b *BatchWriter) flush(ctx context.Context, final bool) error {
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+
+	b.mu.Lock()
+	batches := b.unsent
+	if b.count > 0 {
+		batches = append(batches, &batch{body: b.buf, points: b.count})
+	}
+	b.unsent = nil
+	b.buf = nil
+	b.count = 0
+	b.mu.Unlock()
+
+	var first error
+	for i, next := range batches {
+		err := b.send(ctx, next.body)
+		if err == nil {
+			continue
+		}
+
+		if ctx.Err() != nil || retryable(context.Background(), err) {
+			// The following batches would fail the same way.
+			rest := batches[i:]
+			if !final {
+				b.keep(rest, err)
+				return err
+			}
+
+			points := 0
+			for _, kept := range rest {
+				points += kept.points
+			}
+			err = &DroppedPointsError{Points: points, Err: err}
+			b.report(err)
+			if first == nil {
+				first = err
+			}
+			break
+		}
+
+		// Sending the batch again would not help, the server rejected it
+		// or accepted a part of it.
+		if _, ok := err.(*PartialWriteError); !ok {
+			err = &DroppedPointsError{Points: next.points, Err: err}
+		}
+		b.report(err)
+		if first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// keep stores the batches for the next flush. When they hold more than
+// MaxPending points, the oldest ones are dropped and reported with err.
+func (b *BatchWriter) keep(batches []*batch, err error) {
+	points := 0
+	for _, kept := range batches {
+		points += kept.points
+	}
+
+	dropped := 0
+	for b.maxPending > 0 && points > b.maxPending && len(batches) > 0 {
+		points -= batches[0].points
+		dropped += batches[0].points
+		batches = batches[1:]
+	}
+
+	b.mu.Lock()
+	b.unsent = batches
+	b.mu.Unlock()
+
+	if dropped > 0 {
+		b.report(&DroppedPointsError{Points: dropped, Err: err})
+	}
+}
+
+func (b *BatchWriter) report(err error) {
+	if b.onError != nil {
+		b.onError(err)
+	}
+}
+
+// run starts the background flushes.
+func (b *BatchWriter) run() {
+	if b.interval <= 0 {
+		close(b.done)
+		return
+	}
+
+	go func() {
+		defer close(b.done)
+
+		ticker := time.NewTicker(b.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-b.stop:
+				return
+			case <-ticker.C:
+				err := b.Flush(context.Background())
+				if err != nil && !dropped(err) {
+					b.report(err)
+				}
+			}
+		}
+	}()
+}
+
+// send writes a batch, retrying when it is worth it.
+func (b *BatchWriter) send(ctx context.Context, body []byte) error {
+	encoding := ""
+	if b.compress {
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		if _, err := gz.Write(body); err != nil {
+			return err
+		}
+		if err := gz.Close(); err != nil {
+			return err
+		}
+		body = compressed.Bytes()
+		encoding = "gzip"
+	}
+
+	delay := b.backoff
+	for attempt := 0; ; attempt++ {
+		err := b.writer.post(ctx, bytes.NewReader(body), encoding)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= b.maxRetries || !retryable(ctx, err) {
+			return err
+		}
+
+		// Wait between a half and the whole delay.
+		wait := delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		delay *= 2
+		if delay > b.maxBackoff {
+			delay = b.maxBackoff
+		}
+	}
+}
+
+// dropped tells whether err was already reported by flush.
+func dropped(err error) bool {
+	switch err.(type) {
+	case *DroppedPointsError, *PartialWriteError:
+		return true
+	}
+	return false
+}
+
+// retryable tells whether a failed write may succeed when sent again.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	switch t := err.(type) {
+	case *ServerError:
+		return t.StatusCode/100 == 5
+	case *PartialWriteError:
+		return false
+	}
+	// The request did not get a response, the server is not available.
+	return true
+}
@@ -0,0 +1,92 @@
+package influxql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+// buildContext holds the settings of the statement being built that affect
+// how nested values are rendered.
+type buildContext struct {
+	epoch Precision
+
+	// params collects bind parameters, values are rendered inline when it
+	// is nil.
+	params map[string]interface{}
+}
+
+// contextBuilder is implemented by builders whose output depends on the
+// buildContext.
+type contextBuilder interface {
+	buildWith(ctx *buildContext) (string, error)
+}
+
+// build compiles b within ctx.
+func (ctx *buildContext) build(b Builder) (string, error) {
+	if cb, ok := b.(contextBuilder); ok {
+		return cb.buildWith(ctx)
+	}
+	return b.Build()
+}
+
+// bind adds a scalar value to the bind parameters and returns its
+// placeholder. Named types are bound as their underlying types, so the
+// parameters encode to JSON. Times are bound as RFC3339 strings with
+// nanoseconds, or as integers when an epoch precision is set: InfluxQL reads
+// integers compared with time as nanoseconds, so they are truncated to the
+// precision but not scaled to it.
+func (ctx *buildContext) bind(v interface{}) (string, error) {
+	param, err := bindValue(v, ctx.epoch)
+	if err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("p%d", len(ctx.params)+1)
+	ctx.params[name] = param
+	return "$" + name, nil
+}
+
+func bindValue(v interface{}, precision Precision) (interface{}, error) {
+	switch t := v.(type) {
+	case time.Time:
+		if precision == RFC3339 {
+			return t.UTC().Format(time.RFC3339Nano), nil
+		}
+		n, err := epoch(t, precision)
+		if err != nil {
+			return nil, err
+		}
+		unit := int64(precisionUnits[precision])
+		if n > math.MaxInt64/unit || n < math.MinInt64/unit {
+			return nil, fmt.Errorf(
+				"Time %s is out of the range of epochs in ns.",
+				t.UTC().Format(time.RFC3339Nano),
+			)
+		}
+		return n * unit, nil
+	case json.Number, string, bool, int64, uint64, float64:
+		return t, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	}
+
+	if s, ok := v.(fmt.Stringer); ok {
+		return s.String(), nil
+	}
+	return v, nil
+}
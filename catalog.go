@@ -0,0 +1,558 @@
+package influxql
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FuncKind classifies InfluxQL functions.
+type FuncKind int
+
+// Function kinds.
+const (
+	Aggregate FuncKind = iota
+	Selector
+	Transformation
+	Predictor
+	Math
+)
+
+var funcKindNames = map[FuncKind]string{
+	Aggregate:      "aggregate",
+	Selector:       "selector",
+	Transformation: "transformation",
+	Predictor:      "predictor",
+	Math:           "math",
+}
+
+func (k FuncKind) String() string {
+	if name, ok := funcKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("FuncKind(%d)", int(k))
+}
+
+// ArgType is a set of value types accepted by a function argument.
+type ArgType uint
+
+// Argument types, they can be combined with |.
+const (
+	ArgField ArgType = 1 << iota
+	ArgWildcard
+	ArgRegex
+	ArgCall
+	ArgInteger
+	ArgFloat
+	ArgDuration
+	ArgString
+
+	// ArgNumber accepts both integers and floats.
+	ArgNumber = ArgInteger | ArgFloat
+
+	// argAny is used for values that cannot be classified, like Builder
+	// implementations defined outside of this package.
+	argAny = ^ArgType(0)
+)
+
+var argTypeNames = []struct {
+	t    ArgType
+	name string
+}{
+	{ArgField, "field"},
+	{ArgWildcard, "wildcard"},
+	{ArgRegex, "regex"},
+	{ArgCall, "function"},
+	{ArgInteger, "integer"},
+	{ArgFloat, "float"},
+	{ArgDuration, "duration"},
+	{ArgString, "string"},
+}
+
+func (t ArgType) String() string {
+	names := []string{}
+	for _, n := range argTypeNames {
+		if t&n.t != 0 {
+			names = append(names, n.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "|")
+}
+
+// ArgSpec describes a function argument.
+//
+// Optional arguments may only follow required ones, and a function with a
+// Variadic argument cannot have Optional arguments.
+type ArgSpec struct {
+	Name     string
+	Type     ArgType
+	Optional bool
+	Variadic bool
+}
+
+// TimeGrouping tells whether a function needs a GROUP BY time() clause.
+type TimeGrouping int
+
+// Time grouping requirements.
+const (
+	GroupByTimeOptional TimeGrouping = iota
+	GroupByTimeRequired
+	// GroupByTimeWhenNested means GROUP BY time() is required when the
+	// function is applied to the result of another function.
+	GroupByTimeWhenNested
+)
+
+// FuncSpec describes an InfluxQL function.
+type FuncSpec struct {
+	Name        string
+	Kind        FuncKind
+	Args        []ArgSpec
+	GroupByTime TimeGrouping
+	Doc         string
+}
+
+// Arity returns the minimum and the maximum number of arguments, max is -1
+// when the number of arguments is unlimited.
+func (spec FuncSpec) Arity() (min, max int) {
+	for _, arg := range spec.Args {
+		switch {
+		case arg.Variadic:
+			max = -1
+		case arg.Optional:
+			if max >= 0 {
+				max++
+			}
+		default:
+			min++
+			if max >= 0 {
+				max++
+			}
+		}
+	}
+	return min, max
+}
+
+func (spec FuncSpec) arity() string {
+	min, max := spec.Arity()
+	switch {
+	case max < 0:
+		return fmt.Sprintf("at least %d arguments", min)
+	case min == max:
+		return fmt.Sprintf("%d arguments", min)
+	}
+	return fmt.Sprintf("%d to %d arguments", min, max)
+}
+
+func (spec FuncSpec) validate(args []interface{}) error {
+	min, max := spec.Arity()
+	if len(args) < min || (max >= 0 && len(args) > max) {
+		return fmt.Errorf(
+			"Function %s expects %s, got %d.",
+			spec.Name, spec.arity(), len(args),
+		)
+	}
+
+	extra := len(args) - min
+	i := 0
+	for _, arg := range spec.Args {
+		n := 1
+		switch {
+		case arg.Variadic:
+			n = extra
+		case arg.Optional:
+			if extra == 0 {
+				n = 0
+			} else {
+				extra--
+			}
+		}
+		for ; n > 0; n-- {
+			if argTypeOf(args[i])&arg.Type == 0 {
+				return fmt.Errorf(
+					"Function %s expects %s as argument %d (%s), got %T.",
+					spec.Name, arg.Type, i+1, arg.Name, args[i],
+				)
+			}
+			i++
+		}
+	}
+	return nil
+}
+
+func argTypeOf(arg interface{}) ArgType {
+	switch t := arg.(type) {
+	case *literal:
+		switch v := t.v.(type) {
+		case *F:
+			return ArgCall
+		case Regex:
+			return ArgRegex
+		case Ident:
+			return ArgField
+		case string:
+			if v == "*" {
+				return ArgWildcard
+			}
+			return ArgField
+		case Builder:
+			return argAny
+		}
+		return ArgField
+	case *F:
+		return ArgCall
+	case Regex:
+		return ArgRegex
+	case Ident:
+		return ArgField
+	case time.Duration:
+		return ArgDuration
+	case int, uint, int64, uint64, int32, uint32, int16, uint16, int8, uint8:
+		return ArgInteger
+	case float32, float64:
+		return ArgFloat
+	case json.Number:
+		return ArgNumber
+	case string:
+		return ArgString
+	}
+	return argAny
+}
+
+var (
+	argValue = ArgField | ArgWildcard | ArgRegex
+	argInput = argValue | ArgCall
+)
+
+func unary(name string, kind FuncKind, t ArgType, doc string) FuncSpec {
+	return FuncSpec{
+		Name: name,
+		Kind: kind,
+		Args: []ArgSpec{{Name: "field", Type: t}},
+		Doc:  doc,
+	}
+}
+
+func movingWindow(name string, doc string, warmup bool) FuncSpec {
+	spec := FuncSpec{
+		Name: name,
+		Kind: Transformation,
+		Args: []ArgSpec{
+			{Name: "field", Type: argInput},
+			{Name: "period", Type: ArgInteger},
+			{Name: "hold_period", Type: ArgInteger, Optional: true},
+		},
+		GroupByTime: GroupByTimeWhenNested,
+		Doc:         doc,
+	}
+	if warmup {
+		spec.Args = append(spec.Args, ArgSpec{
+			Name: "warmup_type", Type: ArgString, Optional: true,
+		})
+	}
+	return spec
+}
+
+func derivative(name string, doc string) FuncSpec {
+	return FuncSpec{
+		Name: name,
+		Kind: Transformation,
+		Args: []ArgSpec{
+			{Name: "field", Type: argInput},
+			{Name: "unit", Type: ArgDuration, Optional: true},
+		},
+		GroupByTime: GroupByTimeWhenNested,
+		Doc:         doc,
+	}
+}
+
+func difference(name string, doc string) FuncSpec {
+	spec := unary(name, Transformation, argInput, doc)
+	spec.GroupByTime = GroupByTimeWhenNested
+	return spec
+}
+
+func holtWinters(name string, doc string) FuncSpec {
+	return FuncSpec{
+		Name: name,
+		Kind: Predictor,
+		Args: []ArgSpec{
+			{Name: "function", Type: ArgCall},
+			{Name: "N", Type: ArgInteger},
+			{Name: "S", Type: ArgInteger},
+		},
+		GroupByTime: GroupByTimeRequired,
+		Doc:         doc,
+	}
+}
+
+func topBottom(name string, doc string) FuncSpec {
+	return FuncSpec{
+		Name: name,
+		Kind: Selector,
+		Args: []ArgSpec{
+			{Name: "field", Type: ArgField},
+			{Name: "tag", Type: ArgField, Variadic: true},
+			{Name: "N", Type: ArgInteger},
+		},
+		Doc: doc,
+	}
+}
+
+var builtinFunctions = []FuncSpec{
+	// Aggregations.
+	unary("COUNT", Aggregate, argInput, "Returns the number of non-null field values."),
+	unary("DISTINCT", Aggregate, ArgField, "Returns the list of unique field values."),
+	{
+		Name: "INTEGRAL",
+		Kind: Aggregate,
+		Args: []ArgSpec{
+			{Name: "field", Type: argValue},
+			{Name: "unit", Type: ArgDuration, Optional: true},
+		},
+		Doc: "Returns the area under the curve for subsequent field values.",
+	},
+	unary("MEAN", Aggregate, argValue, "Returns the arithmetic mean of field values."),
+	unary("MEDIAN", Aggregate, argValue, "Returns the middle value from a sorted list of field values."),
+	unary("MODE", Aggregate, argValue, "Returns the most frequent value in a list of field values."),
+	unary("SPREAD", Aggregate, argValue, "Returns the difference between the minimum and maximum field values."),
+	unary("STDDEV", Aggregate, argValue, "Returns the standard deviation of field values."),
+	unary("SUM", Aggregate, argValue, "Returns the sum of field values."),
+
+	// Selectors.
+	topBottom("BOTTOM", "Returns the smallest N field values."),
+	unary("FIRST", Selector, argValue, "Returns the field value with the oldest timestamp."),
+	unary("LAST", Selector, argValue, "Returns the field value with the most recent timestamp."),
+	unary("MAX", Selector, argValue, "Returns the greatest field value."),
+	unary("MIN", Selector, argValue, "Returns the lowest field value."),
+	{
+		Name: "PERCENTILE",
+		Kind: Selector,
+		Args: []ArgSpec{
+			{Name: "field", Type: argValue},
+			{Name: "N", Type: ArgNumber},
+		},
+		Doc: "Returns the Nth percentile field value.",
+	},
+	{
+		Name: "SAMPLE",
+		Kind: Selector,
+		Args: []ArgSpec{
+			{Name: "field", Type: argValue},
+			{Name: "N", Type: ArgInteger},
+		},
+		Doc: "Returns a random sample of N field values.",
+	},
+	topBottom("TOP", "Returns the greatest N field values."),
+
+	// Transformations.
+	difference("CUMULATIVE_SUM", "Returns the running total of subsequent field values."),
+	derivative("DERIVATIVE", "Returns the rate of change between subsequent field values."),
+	difference("DIFFERENCE", "Returns the result of subtraction between subsequent field values."),
+	{
+		Name: "ELAPSED",
+		Kind: Transformation,
+		Args: []ArgSpec{
+			{Name: "field", Type: argValue},
+			{Name: "unit", Type: ArgDuration, Optional: true},
+		},
+		Doc: "Returns the difference between subsequent timestamps.",
+	},
+	{
+		Name: "MOVING_AVERAGE",
+		Kind: Transformation,
+		Args: []ArgSpec{
+			{Name: "field", Type: argInput},
+			{Name: "N", Type: ArgInteger},
+		},
+		GroupByTime: GroupByTimeWhenNested,
+		Doc:         "Returns the rolling average across a window of N field values.",
+	},
+	derivative("NON_NEGATIVE_DERIVATIVE", "Returns the non-negative rate of change between subsequent field values."),
+	difference("NON_NEGATIVE_DIFFERENCE", "Returns the non-negative result of subtraction between subsequent field values."),
+	movingWindow("CHANDE_MOMENTUM_OSCILLATOR", "Returns the Chande Momentum Oscillator.", true),
+	movingWindow("EXPONENTIAL_MOVING_AVERAGE", "Returns the exponential moving average.", true),
+	movingWindow("DOUBLE_EXPONENTIAL_MOVING_AVERAGE", "Returns the double exponential moving average.", true),
+	movingWindow("KAUFMANS_EFFICIENCY_RATIO", "Returns Kaufman's efficiency ratio.", false),
+	movingWindow("KAUFMANS_ADAPTIVE_MOVING_AVERAGE", "Returns Kaufman's adaptive moving average.", false),
+	movingWindow("TRIPLE_EXPONENTIAL_MOVING_AVERAGE", "Returns the triple exponential moving average.", true),
+	movingWindow("TRIPLE_EXPONENTIAL_DERIVATIVE", "Returns the triple exponential derivative.", true),
+	movingWindow("RELATIVE_STRENGTH_INDEX", "Returns the relative strength index.", true),
+
+	// Predictors.
+	holtWinters("HOLT_WINTERS", "Returns N predicted values using the Holt-Winters method."),
+	holtWinters("HOLT_WINTERS_WITH_FIT", "Returns N predicted values and the fitted values using the Holt-Winters method."),
+
+	// Math.
+	unary("ABS", Math, argInput, "Returns the absolute value."),
+	unary("ACOS", Math, argInput, "Returns the arccosine in radians."),
+	unary("ASIN", Math, argInput, "Returns the arcsine in radians."),
+	unary("ATAN", Math, argInput, "Returns the arctangent in radians."),
+	{
+		Name: "ATAN2",
+		Kind: Math,
+		Args: []ArgSpec{
+			{Name: "y", Type: argInput | ArgNumber},
+			{Name: "x", Type: argInput | ArgNumber},
+		},
+		Doc: "Returns the arctangent of y/x in radians.",
+	},
+	unary("CEIL", Math, argInput, "Returns the value rounded up to the nearest integer."),
+	unary("COS", Math, argInput, "Returns the cosine."),
+	unary("EXP", Math, argInput, "Returns the exponential."),
+	unary("FLOOR", Math, argInput, "Returns the value rounded down to the nearest integer."),
+	unary("LN", Math, argInput, "Returns the natural logarithm."),
+	{
+		Name: "LOG",
+		Kind: Math,
+		Args: []ArgSpec{
+			{Name: "field", Type: argInput},
+			{Name: "base", Type: ArgNumber},
+		},
+		Doc: "Returns the logarithm with the given base.",
+	},
+	unary("LOG2", Math, argInput, "Returns the base 2 logarithm."),
+	unary("LOG10", Math, argInput, "Returns the base 10 logarithm."),
+	{
+		Name: "POW",
+		Kind: Math,
+		Args: []ArgSpec{
+			{Name: "field", Type: argInput},
+			{Name: "x", Type: ArgNumber},
+		},
+		Doc: "Returns the value to the power of x.",
+	},
+	unary("ROUND", Math, argInput, "Returns the value rounded to the nearest integer."),
+	unary("SIN", Math, argInput, "Returns the sine."),
+	unary("SQRT", Math, argInput, "Returns the square root."),
+	unary("TAN", Math, argInput, "Returns the tangent."),
+}
+
+var (
+	functionCatalog   = map[string]FuncSpec{}
+	functionCatalogMu sync.RWMutex
+)
+
+var reFuncName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func init() {
+	for _, spec := range builtinFunctions {
+		functionCatalog[spec.Name] = spec
+	}
+}
+
+func (spec FuncSpec) check() error {
+	if !reFuncName.MatchString(spec.Name) {
+		return fmt.Errorf("Invalid function name %q.", spec.Name)
+	}
+	if _, ok := funcKindNames[spec.Kind]; !ok {
+		return fmt.Errorf("Function %s has unknown kind %s.", spec.Name, spec.Kind)
+	}
+
+	var optional, variadic bool
+	for i, arg := range spec.Args {
+		switch {
+		case arg.Type == 0:
+			return fmt.Errorf(
+				"Function %s argument %d accepts no types.", spec.Name, i+1,
+			)
+		case arg.Optional && arg.Variadic:
+			return fmt.Errorf(
+				"Function %s argument %d cannot be both optional and variadic.",
+				spec.Name, i+1,
+			)
+		case arg.Variadic:
+			if variadic || optional {
+				return fmt.Errorf(
+					"Function %s can have only one variadic argument and no optional ones.",
+					spec.Name,
+				)
+			}
+			variadic = true
+		case arg.Optional:
+			if variadic {
+				return fmt.Errorf(
+					"Function %s can have only one variadic argument and no optional ones.",
+					spec.Name,
+				)
+			}
+			optional = true
+		case optional:
+			return fmt.Errorf(
+				"Function %s argument %d is required but follows an optional one.",
+				spec.Name, i+1,
+			)
+		}
+	}
+	return nil
+}
+
+// RegisterFunc adds a function to the catalog, so Func calls with its name
+// are validated like the built-in functions. It is meant for functions
+// provided by forks and Enterprise builds of InfluxDB, registering a name
+// that is already known fails.
+func RegisterFunc(spec FuncSpec) error {
+	spec.Name = strings.ToUpper(spec.Name)
+	spec.Args = append([]ArgSpec(nil), spec.Args...)
+
+	if err := spec.check(); err != nil {
+		return err
+	}
+
+	functionCatalogMu.Lock()
+	defer functionCatalogMu.Unlock()
+
+	if _, ok := functionCatalog[spec.Name]; ok {
+		return fmt.Errorf("Function %s is already registered.", spec.Name)
+	}
+	functionCatalog[spec.Name] = spec
+	return nil
+}
+
+// unregisterFunc removes a function added by RegisterFunc, it is used by
+// tests to restore the catalog.
+func unregisterFunc(name string) {
+	functionCatalogMu.Lock()
+	defer functionCatalogMu.Unlock()
+
+	delete(functionCatalog, strings.ToUpper(name))
+}
+
+// MustRegisterFunc is like RegisterFunc but panics on error.
+func MustRegisterFunc(spec FuncSpec) {
+	if err := RegisterFunc(spec); err != nil {
+		panic(err)
+	}
+}
+
+// LookupFunc returns the description of the named function.
+func LookupFunc(name string) (FuncSpec, bool) {
+	functionCatalogMu.RLock()
+	defer functionCatalogMu.RUnlock()
+
+	spec, ok := functionCatalog[strings.ToUpper(name)]
+	spec.Args = append([]ArgSpec(nil), spec.Args...)
+	return spec, ok
+}
+
+// Functions returns descriptions of all known functions sorted by name.
+func Functions() []FuncSpec {
+	functionCatalogMu.RLock()
+	defer functionCatalogMu.RUnlock()
+
+	specs := make([]FuncSpec, 0, len(functionCatalog))
+	for _, spec := range functionCatalog {
+		spec.Args = append([]ArgSpec(nil), spec.Args...)
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].Name < specs[j].Name
+	})
+	return specs
+}
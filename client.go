@@ -0,0 +1,280 @@
+package influxql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Client executes statements against the /query endpoint of InfluxDB. It is
+// configured with chained calls, like the statement builders, and is safe for
+// concurrent use once configured.
+type Client struct {
+	addr       string
+	httpClient *http.Client
+
+	username string
+	password string
+	token    string
+
+	database        string
+	retentionPolicy string
+	epoch           Precision
+	chunkSize       int
+	chunked         bool
+	bindParams      bool
+	format          Format
+}
+
+// Format is the media type of /query responses.
+type Format string
+
+// Response formats.
+const (
+	JSON        Format = "application/json"
+	CSV         Format = "application/csv"
+	MessagePack Format = "application/x-msgpack"
+)
+
+// NewClient creates a client for the InfluxDB server at addr, like
+// http://localhost:8086.
+func NewClient(addr string) *Client {
+	return &Client{
+		addr:       strings.TrimRight(addr, "/"),
+		httpClient: http.DefaultClient,
+		format:     JSON,
+	}
+}
+
+// HTTPClient sets the HTTP client used to send requests.
+func (c *Client) HTTPClient(httpClient *http.Client) *Client {
+	c.httpClient = httpClient
+	return c
+}
+
+// BasicAuth authenticates requests with a username and a password, it
+// replaces the token set by Token.
+func (c *Client) BasicAuth(username string, password string) *Client {
+	c.username = username
+	c.password = password
+	c.token = ""
+	return c
+}
+
+// Token authenticates requests with the Authorization: Token header, it
+// replaces the credentials set by BasicAuth.
+func (c *Client) Token(token string) *Client {
+	c.token = token
+	c.username = ""
+	c.password = ""
+	return c
+}
+
+// Database sets the db argument, the database statements run against.
+func (c *Client) Database(name string) *Client {
+	c.database = name
+	return c
+}
+
+// RetentionPolicy sets the rp argument, the retention policy used when a
+// statement does not specify one.
+func (c *Client) RetentionPolicy(name string) *Client {
+	c.retentionPolicy = name
+	return c
+}
+
+// Epoch sets the epoch argument, so times are returned as epoch integers with
+// the given precision instead of RFC3339 strings.
+func (c *Client) Epoch(precision Precision) *Client {
+	c.epoch = precision
+	return c
+}
+
+// Chunked makes the server stream the results in chunks of size points, zero
+// means the server default.
+func (c *Client) Chunked(size int) *Client {
+	c.chunked = true
+	c.chunkSize = size
+	return c
+}
+
+// BindParams makes the client build statements implementing ParamsBuilder
+// with BuildParams and send the values in the params argument, so they are
+// never interpolated into the query text.
+func (c *Client) BindParams() *Client {
+	c.bindParams = true
+	return c
+}
+
+// Format sets the format requested with the Accept header, JSON by default.
+// Stream always requests JSON.
+func (c *Client) Format(format Format) *Client {
+	c.format = format
+	return c
+}
+
+// ServerError is returned when InfluxDB responds with an error status.
+type ServerError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("influxdb: %d %s: %s",
+		e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// readOnly tells whether the statement can be sent with GET, statements that
+// are not known to be read-only are sent with POST.
+func readOnly(b Builder, query string) bool {
+	switch b.(type) {
+	case *SelectBuilder, *ShowFieldKeysBuilder, *ShowMeasurementsBuilder,
+		*ShowRetentionPoliciesBuilder, *ShowTagKeysBuilder, *ShowTagValuesBuilder:
+		return true
+	case *DeleteBuilder, *CreateDatabaseBuilder, *CreateRetentionPolicyBuilder,
+		*CreateUserBuilder:
+		return false
+	}
+
+	tokens, err := scanExpr(query)
+	if err != nil {
+		return false
+	}
+
+	words := []string{}
+	for _, token := range tokens {
+		words = append(words, strings.Fields(strings.ToUpper(token.text))...)
+	}
+	if len(words) == 0 || (words[0] != "SELECT" && words[0] != "SHOW") {
+		return false
+	}
+	for _, word := range words {
+		if word == "INTO" || strings.Contains(word, ";") {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Client) build(b Builder) (string, map[string]interface{}, error) {
+	if pb, ok := b.(ParamsBuilder); ok && c.bindParams {
+		return pb.BuildParams()
+	}
+	q, err := b.Build()
+	return q, nil, err
+}
+
+func (c *Client) newRequest(
+	ctx context.Context, b Builder, accept string,
+) (*http.Request, error) {
+	q, params, err := c.build(b)
+	if err != nil {
+		return nil, err
+	}
+
+	values := url.Values{}
+	values.Set("q", q)
+	if c.database != "" {
+		values.Set("db", c.database)
+	}
+	if c.retentionPolicy != "" {
+		values.Set("rp", c.retentionPolicy)
+	}
+	if c.epoch != RFC3339 {
+		values.Set("epoch", string(c.epoch))
+	}
+	if c.chunked {
+		values.Set("chunked", "true")
+		if c.chunkSize > 0 {
+			values.Set("chunk_size", strconv.Itoa(c.chunkSize))
+		}
+	}
+	if len(params) > 0 {
+		encoded, err := json.Marshal(params)
+		if err != nil {
+			return nil, err
+		}
+		values.Set("params", string(encoded))
+	}
+
+	var req *http.Request
+	if readOnly(b, q) {
+		req, err = http.NewRequest(
+			http.MethodGet, c.addr+"/query?"+values.Encode(), nil,
+		)
+	} else {
+		req, err = http.NewRequest(
+			http.MethodPost, c.addr+"/query", strings.NewReader(values.Encode()),
+		)
+		if req != nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.authorize(req)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	return req.WithContext(ctx), nil
+}
+
+// authorize sets the credentials of the request.
+func (c *Client) authorize(req *http.Request) {
+	switch {
+	case c.token != "":
+		req.Header.Set("Authorization", "Token "+c.token)
+	case c.username != "" || c.password != "":
+		req.SetBasicAuth(c.username, c.password)
+	}
+}
+
+// Exec sends the statement built by b to /query. It returns the raw response
+// when the server replies with a success status, the caller must close its
+// body.
+func (c *Client) Exec(ctx context.Context, b Builder) (*http.Response, error) {
+	return c.exec(ctx, b, "")
+}
+
+func (c *Client) exec(
+	ctx context.Context, b Builder, accept string,
+) (*http.Response, error) {
+	req, err := c.newRequest(ctx, b, accept)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		return nil, readServerError(resp)
+	}
+
+	return resp, nil
+}
+
+func readServerError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	var payload struct {
+		Error string `json:"error"`
+	}
+	message := strings.TrimSpace(string(body))
+	if json.Unmarshal(body, &payload) == nil && payload.Error != "" {
+		message = payload.Error
+	}
+
+	return &ServerError{StatusCode: resp.StatusCode, Message: message}
+}
@@ -0,0 +1,683 @@
+package influxql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedRequest struct {
+	method string
+	form   url.Values
+	header http.Header
+}
+
+func newTestServer(t *testing.T, status int, body string) (*httptest.Server, *recordedRequest) {
+	rec := &recordedRequest{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		rec.method = r.Method
+		rec.form = r.Form
+		rec.header = r.Header
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, rec
+}
+
+func TestClientExec(t *testing.T) {
+	srv, rec := newTestServer(t, http.StatusOK, `{"results":[{"statement_id":0}]}`)
+
+	c := NewClient(srv.URL+"/").
+		Database("telegraf").
+		RetentionPolicy("autogen").
+		Epoch(PrecisionMillisecond).
+		Chunked(100).
+		BasicAuth("user", "secret")
+
+	resp, err := c.Exec(context.Background(), Select("value").From("cpu"))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	assert.Equal(t, `{"results":[{"statement_id":0}]}`, string(body))
+	assert.Equal(t, http.MethodGet, rec.method)
+	assert.Equal(t, `SELECT "value" FROM "cpu"`, rec.form.Get("q"))
+	assert.Equal(t, "telegraf", rec.form.Get("db"))
+	assert.Equal(t, "autogen", rec.form.Get("rp"))
+	assert.Equal(t, "ms", rec.form.Get("epoch"))
+	assert.Equal(t, "true", rec.form.Get("chunked"))
+	assert.Equal(t, "100", rec.form.Get("chunk_size"))
+	assert.Equal(t, "", rec.form.Get("params"))
+
+	username, password, ok := (&http.Request{Header: rec.header}).BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "secret", password)
+}
+
+func TestClientMethod(t *testing.T) {
+	srv, rec := newTestServer(t, http.StatusOK, `{"results":[]}`)
+	c := NewClient(srv.URL)
+
+	tests := []struct {
+		b      Builder
+		method string
+	}{
+		{Select("value").From("cpu"), http.MethodGet},
+		{ShowMeasurements(), http.MethodGet},
+		{Delete().From("cpu"), http.MethodPost},
+		{CreateDatabase("db"), http.MethodPost},
+		{Raw(`SHOW DATABASES`), http.MethodGet},
+		{Raw(`select * from "cpu" where "host" = 'a;b'`), http.MethodPost},
+		{Raw(`SELECT * INTO "copy" FROM "cpu"`), http.MethodPost},
+		{Raw(`DROP MEASUREMENT "cpu"`), http.MethodPost},
+		{Raw(`SELECT 1; DROP DATABASE "db"`), http.MethodPost},
+	}
+
+	for _, test := range tests {
+		resp, err := c.Exec(context.Background(), test.b)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+
+		q, err := test.b.Build()
+		require.NoError(t, err)
+		assert.Equal(t, test.method, rec.method, q)
+		assert.Equal(t, q, rec.form.Get("q"))
+	}
+}
+
+func TestClientBindParams(t *testing.T) {
+	srv, rec := newTestServer(t, http.StatusOK, `{"results":[]}`)
+	c := NewClient(srv.URL).Token("abc").BindParams()
+
+	resp, err := c.Exec(
+		context.Background(),
+		Select("value").From("cpu").Where(Eq("host", "server'01")),
+	)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	assert.Equal(t, `SELECT "value" FROM "cpu" WHERE "host" = $p1`, rec.form.Get("q"))
+	var params map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(rec.form.Get("params")), &params))
+	assert.Equal(t, map[string]interface{}{"p1": "server'01"}, params)
+	assert.Equal(t, "Token abc", rec.header.Get("Authorization"))
+}
+
+func TestClientAuthReplaced(t *testing.T) {
+	srv, rec := newTestServer(t, http.StatusOK, `{"results":[]}`)
+
+	resp, err := NewClient(srv.URL).BasicAuth("user", "secret").Token("abc").
+		Exec(context.Background(), ShowMeasurements())
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, "Token abc", rec.header.Get("Authorization"))
+
+	resp, err = NewClient(srv.URL).Token("abc").BasicAuth("user", "secret").
+		Exec(context.Background(), ShowMeasurements())
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	username, password, ok := (&http.Request{Header: rec.header}).BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "secret", password)
+}
+
+func TestClientServerError(t *testing.T) {
+	srv, _ := newTestServer(
+		t, http.StatusUnauthorized, `{"error":"authorization failed"}`,
+	)
+
+	_, err := NewClient(srv.URL).Exec(context.Background(), ShowMeasurements())
+	require.Error(t, err)
+
+	serverErr, ok := err.(*ServerError)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusUnauthorized, serverErr.StatusCode)
+	assert.Equal(t, "authorization failed", serverErr.Message)
+}
+
+func TestClientContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := NewClient(srv.URL).Exec(ctx, ShowMeasurements())
+	require.Error(t, err)
+	assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+}
+
+func TestDecodeResponse(t *testing.T) {
+	body := `{"results":[
+		{"statement_id":0,"series":[{
+			"name":"cpu","tags":{"host":"a"},
+			"columns":["time","value","count","big","ok","note"],
+			"values":[
+				["2020-01-02T03:04:05.123456789Z",1.5,3,18446744073709551615,true,"x"],
+				["2020-01-02T03:04:06Z",null,null,null,null,null]
+			]
+		}],"messages":[{"level":"warning","text":"deprecated"}]},
+		{"statement_id":1,"error":"measurement not found"}
+	]}`
+
+	resp, err := DecodeResponse(strings.NewReader(body), RFC3339)
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 2)
+
+	series := resp.Results[0].Series[0]
+	assert.Equal(t, "cpu", series.Name)
+	assert.Equal(t, map[string]string{"host": "a"}, series.Tags)
+	assert.Equal(t, []interface{}{
+		time.Date(2020, 1, 2, 3, 4, 5, 123456789, time.UTC),
+		1.5, int64(3), uint64(math.MaxUint64), true, "x",
+	}, series.Values[0])
+	assert.Equal(t, []interface{}{
+		time.Date(2020, 1, 2, 3, 4, 6, 0, time.UTC),
+		nil, nil, nil, nil, nil,
+	}, series.Values[1])
+	assert.Equal(t, []*Message{{Level: "warning", Text: "deprecated"}},
+		resp.Results[0].Messages)
+
+	rows := series.Rows()
+	require.Len(t, rows, 2)
+	host, ok := rows[0].Value("host")
+	assert.True(t, ok)
+	assert.Equal(t, "a", host)
+	ts, ok := rows[1].Time()
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 6, 0, time.UTC), ts)
+
+	assert.NoError(t, resp.Results[0].Error())
+	assert.EqualError(t, resp.Error(), "influxdb: statement 1: measurement not found")
+}
+
+func TestDecodeResponseEpoch(t *testing.T) {
+	body := `{"results":[{"statement_id":0,"series":[{
+		"name":"cpu","columns":["time","value"],"values":[[1577934245123,1]]
+	}]}]}`
+
+	resp, err := DecodeResponse(strings.NewReader(body), PrecisionMillisecond)
+	require.NoError(t, err)
+	assert.Equal(t,
+		time.Date(2020, 1, 2, 3, 4, 5, 123000000, time.UTC),
+		resp.Results[0].Series[0].Values[0][0],
+	)
+
+	_, err = DecodeResponse(strings.NewReader(`{"results":[{"series":[{
+		"columns":["time"],"values":[["yesterday"]]
+	}]}]}`), RFC3339)
+	assert.Error(t, err)
+
+	_, err = DecodeResponse(strings.NewReader(`{"results":[{"series":[{
+		"name":"cpu","columns":["time"],"values":[[9223372036854775]]
+	}]}]}`), PrecisionMillisecond)
+	assert.EqualError(t, err, `Invalid value of column "time" in series "cpu": `+
+		`Epoch 9223372036854775 is out of the range of times in ms.`)
+}
+
+func TestClientQuery(t *testing.T) {
+	srv, rec := newTestServer(t, http.StatusOK, `{"results":[
+		{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[[10,2]]}]},
+		{"statement_id":1,"error":"boom"}
+	]}`)
+
+	resp, err := NewClient(srv.URL).Epoch(PrecisionSecond).Query(
+		context.Background(), Raw(`SELECT "value" FROM "cpu"; SELECT "x" FROM "y"`),
+	)
+	require.NotNil(t, resp)
+	assert.Equal(t, &StatementError{StatementID: 1, Message: "boom"}, err)
+	assert.Equal(t, "application/json", rec.header.Get("Accept"))
+	assert.Equal(t, time.Unix(10, 0).UTC(), resp.Results[0].Series[0].Values[0][0])
+
+	srv, _ = newTestServer(t, http.StatusOK, `{"error":"error parsing query"}`)
+	resp, err = NewClient(srv.URL).Query(context.Background(), Raw(`SELEC`))
+	require.NotNil(t, resp)
+	assert.EqualError(t, err, "influxdb: error parsing query")
+}
+
+type testBase struct {
+	Host string `influx:"host"`
+}
+
+type testPoint struct {
+	testBase
+	Time    time.Time `influx:"time"`
+	Region  string    `influx:"region"`
+	Mean    *float64  `influx:"mean_usage"`
+	Count   int32     `influx:"count"`
+	Ignored string    `influx:"-"`
+}
+
+func TestScanSeries(t *testing.T) {
+	body := `{"results":[{"statement_id":0,"series":[
+		{"name":"cpu","tags":{"host":"a","region":"eu","dc":"x"},
+		 "columns":["time","mean_usage","count"],
+		 "values":[["2020-01-01T00:00:00Z",1.5,2],["2020-01-01T00:01:00Z",null,3]]},
+		{"name":"cpu","tags":{"host":"b","region":"us"},
+		 "columns":["time","mean_usage","count"],
+		 "values":[["2020-01-01T00:00:00Z",2,0]]}
+	]}]}`
+
+	q := Select(Mean("usage").As("mean_usage"), Count("usage")).
+		From("cpu").
+		GroupBy(Time(time.Minute), "host", "region")
+	s, err := q.Build()
+	require.NoError(t, err)
+	assert.Contains(t, s, `MEAN("usage") AS "mean_usage"`)
+
+	resp, err := DecodeResponse(strings.NewReader(body), RFC3339)
+	require.NoError(t, err)
+
+	var points []testPoint
+	require.NoError(t, resp.Results[0].Scan(&points))
+
+	one, two := 1.5, 2.0
+	minute := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, []testPoint{
+		{testBase{"a"}, minute, "eu", &one, 2, ""},
+		{testBase{"a"}, minute.Add(time.Minute), "eu", nil, 3, ""},
+		{testBase{"b"}, minute, "us", &two, 0, ""},
+	}, points)
+
+	var ptrs []*testPoint
+	require.NoError(t, resp.Results[0].Series[1].Scan(&ptrs))
+	require.Len(t, ptrs, 1)
+	assert.Equal(t, "b", ptrs[0].Host)
+}
+
+func TestScanSeriesErrors(t *testing.T) {
+	series := &Series{
+		Name:    "cpu",
+		Columns: []string{"time", "usage"},
+		Values:  [][]interface{}{{time.Unix(0, 0), 1.5}},
+	}
+
+	var points []testPoint
+	assert.EqualError(t, series.Scan(&points),
+		`Column "usage" of series "cpu" has no matching field in influxql.testPoint.`)
+	assert.EqualError(t, series.Scan(points),
+		"Scan destination must be a pointer to a slice, got []influxql.testPoint.")
+
+	series.Columns = []string{"time", "count"}
+	assert.EqualError(t, series.Scan(&points),
+		`Unable to scan column "count" of series "cpu": cannot assign 1.5 to int32.`)
+
+	series.Values = [][]interface{}{{time.Unix(0, 0), nil}}
+	assert.EqualError(t, series.Scan(&points),
+		`Unable to scan column "count" of series "cpu": cannot assign null to int32, use a pointer.`)
+
+	series.Values = [][]interface{}{{"now", int64(1)}}
+	assert.EqualError(t, series.Scan(&points),
+		`Unable to scan column "time" of series "cpu": cannot assign string to time.Time.`)
+}
+
+const testChunks = `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"a"},"columns":["time","value"],"values":[[1,1],[2,2]],"partial":true}],"partial":true}]}
+{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"a"},"columns":["time","value"],"values":[[3,3]]},{"name":"cpu","tags":{"host":"b"},"columns":["time","value"],"values":[[1,4]],"partial":true}],"partial":true}]}
+{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"b"},"columns":["time","value"],"values":[[2,5]]}]}]}
+{"results":[{"statement_id":1,"series":[{"name":"mem","columns":["time","value"],"values":[[1,6]]}]}]}
+`
+
+func TestSeriesIterator(t *testing.T) {
+	srv, rec := newTestServer(t, http.StatusOK, testChunks)
+
+	it, err := NewClient(srv.URL).Epoch(PrecisionSecond).Stream(
+		context.Background(), Raw(`SELECT "value" FROM "cpu" GROUP BY "host"`),
+	)
+	require.NoError(t, err)
+	defer it.Close()
+	assert.Equal(t, "true", rec.form.Get("chunked"))
+
+	type item struct {
+		statementID int
+		name        string
+		tags        map[string]string
+		values      int
+		partial     bool
+		continued   bool
+	}
+	var items []item
+	for it.Next() {
+		s := it.Series()
+		items = append(items, item{
+			it.StatementID(), s.Name, s.Tags, len(s.Values), s.Partial, it.Continued(),
+		})
+	}
+	require.NoError(t, it.Err())
+
+	assert.Equal(t, []item{
+		{0, "cpu", map[string]string{"host": "a"}, 3, false, false},
+		{0, "cpu", map[string]string{"host": "b"}, 2, false, false},
+		{1, "mem", nil, 1, false, false},
+	}, items)
+
+	// Series longer than MaxRows are yielded in parts.
+	it = NewSeriesIterator(
+		context.Background(), io.NopCloser(strings.NewReader(testChunks)), PrecisionSecond,
+	).MaxRows(2)
+	defer it.Close()
+
+	items = nil
+	var values []interface{}
+	for it.Next() {
+		s := it.Series()
+		items = append(items, item{
+			it.StatementID(), s.Name, s.Tags, len(s.Values), s.Partial, it.Continued(),
+		})
+		for _, row := range s.Values {
+			values = append(values, row[1])
+		}
+	}
+	require.NoError(t, it.Err())
+
+	assert.Equal(t, []item{
+		{0, "cpu", map[string]string{"host": "a"}, 2, true, false},
+		{0, "cpu", map[string]string{"host": "a"}, 1, false, true},
+		{0, "cpu", map[string]string{"host": "b"}, 2, false, false},
+		{1, "mem", nil, 1, false, false},
+	}, items)
+	assert.Equal(t, []interface{}{
+		int64(1), int64(2), int64(3), int64(4), int64(5), int64(6),
+	}, values)
+}
+
+func TestSeriesIteratorError(t *testing.T) {
+	body := `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time"],"values":[[1]]}]}]}
+{"results":[{"statement_id":1,"error":"boom"}]}
+`
+	it := NewSeriesIterator(
+		context.Background(), io.NopCloser(strings.NewReader(body)), RFC3339,
+	)
+	defer it.Close()
+
+	assert.True(t, it.Next())
+	assert.Equal(t, "cpu", it.Series().Name)
+	assert.False(t, it.Next())
+	assert.Equal(t, &StatementError{StatementID: 1, Message: "boom"}, it.Err())
+}
+
+func TestDecodeResponseChunked(t *testing.T) {
+	resp, err := DecodeResponse(strings.NewReader(testChunks), PrecisionSecond)
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 2)
+	require.Len(t, resp.Results[0].Series, 2)
+	assert.Len(t, resp.Results[0].Series[0].Values, 3)
+	assert.Len(t, resp.Results[0].Series[1].Values, 2)
+	assert.False(t, resp.Results[0].Partial)
+	assert.Equal(t, "mem", resp.Results[1].Series[0].Name)
+}
+
+func TestSeriesIteratorContext(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time"],"values":[[1]],"partial":true}],"partial":true}]}` + "\n"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(srv.Close)
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	it, err := NewClient(srv.URL).Stream(ctx, Raw(`SELECT * FROM "cpu"`))
+	require.NoError(t, err)
+	it.MaxRows(1)
+	defer it.Close()
+
+	// The first part of the series is yielded before the rest is sent.
+	assert.True(t, it.Next())
+	assert.True(t, it.Series().Partial)
+	cancel()
+	assert.False(t, it.Next())
+	assert.Equal(t, context.Canceled, it.Err())
+}
+
+func TestDecodeCSVResponse(t *testing.T) {
+	body := "name,tags,time,value,ok,note\n" +
+		"cpu,\"host=a\\,b,region=eu\",1577836800000000000,1.5,true,x\n" +
+		"cpu,\"host=a\\,b,region=eu\",1577836860000000000,,,\n" +
+		"cpu,host=c,1577836800000000000,2,false,\"y, z\"\n" +
+		"\n" +
+		"name,tags,name\n" +
+		"measurements,,cpu\n"
+
+	resp, err := DecodeCSVResponse(strings.NewReader(body), RFC3339)
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 2)
+
+	minute := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, []*Series{
+		{
+			Name:    "cpu",
+			Tags:    map[string]string{"host": "a,b", "region": "eu"},
+			Columns: []string{"time", "value", "ok", "note"},
+			Values: [][]interface{}{
+				{minute, 1.5, true, "x"},
+				{minute.Add(time.Minute), nil, nil, nil},
+			},
+		},
+		{
+			Name:    "cpu",
+			Tags:    map[string]string{"host": "c"},
+			Columns: []string{"time", "value", "ok", "note"},
+			Values:  [][]interface{}{{minute, int64(2), false, "y, z"}},
+		},
+	}, resp.Results[0].Series)
+	assert.Equal(t, 1, resp.Results[1].StatementID)
+	assert.Equal(t, [][]interface{}{{"cpu"}}, resp.Results[1].Series[0].Values)
+
+	resp, err = DecodeCSVResponse(strings.NewReader("error\n\"error parsing query\"\n"), RFC3339)
+	require.NoError(t, err)
+	assert.EqualError(t, resp.Error(), "influxdb: statement 0: error parsing query")
+
+	_, err = DecodeCSVResponse(strings.NewReader("cpu,,1\n"), RFC3339)
+	assert.EqualError(t, err, "Unable to decode CSV response: missing header on line 1.")
+}
+
+func TestDecodeCSVResponseStatements(t *testing.T) {
+	// The columns of the first statement change without a blank line, the
+	// second statement returns no series and is not written, the third one
+	// fails.
+	body := "name,tags,time,value\n" +
+		"cpu,host=a,1,1\n" +
+		"name,tags,time,value,idle\n" +
+		"cpu,host=b,1,2,3\n" +
+		"\n" +
+		"name,tags,time,free\n" +
+		"mem,,1,4\n" +
+		"error\n" +
+		"\"measurement not found\"\n"
+
+	resp, err := DecodeCSVResponse(strings.NewReader(body), PrecisionSecond)
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 2)
+	assert.Empty(t, resp.Err)
+
+	first := resp.Results[0]
+	assert.Equal(t, 0, first.StatementID)
+	require.Len(t, first.Series, 2)
+	assert.Equal(t, []string{"time", "value"}, first.Series[0].Columns)
+	assert.Equal(t, map[string]string{"host": "b"}, first.Series[1].Tags)
+	assert.Equal(t, []string{"time", "value", "idle"}, first.Series[1].Columns)
+	assert.Empty(t, first.Err)
+
+	second := resp.Results[1]
+	assert.Equal(t, 1, second.StatementID)
+	require.Len(t, second.Series, 1)
+	assert.Equal(t, "mem", second.Series[0].Name)
+	assert.Equal(t, "measurement not found", second.Err)
+
+	// Blank lines inside quoted values do not separate results.
+	resp, err = DecodeCSVResponse(strings.NewReader("name,tags,time,note\ncpu,,1,\"a\n\nb\"\ncpu,,2,c\n"), RFC3339)
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 1)
+	assert.Len(t, resp.Results[0].Series[0].Values, 2)
+
+	_, err = DecodeCSVResponse(strings.NewReader("name,tags,time\ncpu,,1\n\ncpu,,2\n"), RFC3339)
+	assert.EqualError(t, err, "Unable to decode CSV response: missing header on line 4.")
+}
+
+func TestDecodeCSVResponseMatchesJSON(t *testing.T) {
+	jsonBody := `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"a"},"columns":["time","value"],"values":[[1000,1],[2000,null]]}]}]}`
+	csvBody := "name,tags,time,value\ncpu,host=a,1000,1\ncpu,host=a,2000,\n"
+
+	fromJSON, err := DecodeResponse(strings.NewReader(jsonBody), PrecisionMillisecond)
+	require.NoError(t, err)
+	fromCSV, err := DecodeCSVResponse(strings.NewReader(csvBody), PrecisionMillisecond)
+	require.NoError(t, err)
+	assert.Equal(t, fromJSON, fromCSV)
+}
+
+func TestClientQueryCSV(t *testing.T) {
+	var accept string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept")
+		w.Header().Set("Content-Type", "application/csv")
+		_, _ = w.Write([]byte("name,tags,time,value\ncpu,,10,1\n"))
+	}))
+	t.Cleanup(srv.Close)
+
+	resp, err := NewClient(srv.URL).Format(CSV).Epoch(PrecisionSecond).Query(
+		context.Background(), Select("value").From("cpu"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "application/csv", accept)
+	assert.Equal(t,
+		[]interface{}{time.Unix(10, 0).UTC(), int64(1)},
+		resp.Results[0].Series[0].Values[0],
+	)
+}
+
+var update = flag.Bool("update", false, "update golden files")
+
+// dumpResponse renders a response with the type of every value, so golden
+// files catch type changes.
+func dumpResponse(resp *Response) string {
+	var b strings.Builder
+	if resp.Err != "" {
+		fmt.Fprintf(&b, "error: %s\n", resp.Err)
+	}
+	for _, result := range resp.Results {
+		fmt.Fprintf(&b, "result %d", result.StatementID)
+		if result.Err != "" {
+			fmt.Fprintf(&b, " error: %s", result.Err)
+		}
+		if result.Partial {
+			b.WriteString(" partial")
+		}
+		b.WriteString("\n")
+		for _, message := range result.Messages {
+			fmt.Fprintf(&b, "  message %s: %s\n", message.Level, message.Text)
+		}
+		for _, series := range result.Series {
+			fmt.Fprintf(&b, "  series %q %v", series.Name, series.Tags)
+			if series.Partial {
+				b.WriteString(" partial")
+			}
+			fmt.Fprintf(&b, "\n    columns %q\n", series.Columns)
+			for _, values := range series.Values {
+				b.WriteString("    row")
+				for _, v := range values {
+					s := fmt.Sprint(v)
+					if t, ok := v.(time.Time); ok {
+						s = t.Format(time.RFC3339Nano)
+					}
+					fmt.Fprintf(&b, " %T(%s)", v, s)
+				}
+				b.WriteString("\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+func TestDecodeMsgpackResponse(t *testing.T) {
+	tests := []struct {
+		name  string
+		epoch Precision
+	}{
+		{"query", RFC3339},
+		{"query_epoch", PrecisionMillisecond},
+		{"query_chunked", RFC3339},
+		{"query_error", RFC3339},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload, err := os.ReadFile(filepath.Join("testdata", test.name+".msgpack"))
+			require.NoError(t, err)
+
+			resp, err := DecodeMsgpackResponse(bytes.NewReader(payload), test.epoch)
+			require.NoError(t, err)
+			got := dumpResponse(resp)
+
+			golden := filepath.Join("testdata", test.name+".golden")
+			if *update {
+				require.NoError(t, os.WriteFile(golden, []byte(got), 0644))
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), got)
+
+			// A payload truncated between two chunks is only missing the
+			// rest of a partial result.
+			for n := 0; n < len(payload); n++ {
+				resp, err := DecodeMsgpackResponse(bytes.NewReader(payload[:n]), test.epoch)
+				if err == nil {
+					assert.True(t, resp.Results[len(resp.Results)-1].Partial,
+						"truncated to %d bytes", n)
+				}
+			}
+		})
+	}
+}
+
+func TestClientQueryMsgpack(t *testing.T) {
+	payload, err := os.ReadFile(filepath.Join("testdata", "query_epoch.msgpack"))
+	require.NoError(t, err)
+
+	var accept string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept")
+		w.Header().Set("Content-Type", "application/x-msgpack")
+		_, _ = w.Write(payload)
+	}))
+	t.Cleanup(srv.Close)
+
+	resp, err := NewClient(srv.URL).Format(MessagePack).Epoch(PrecisionMillisecond).Query(
+		context.Background(), Select("usage").From("cpu"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "application/x-msgpack", accept)
+	assert.Equal(t,
+		[]interface{}{time.Date(2020, 1, 1, 0, 0, 0, 123000000, time.UTC), 0.5},
+		resp.Results[0].Series[0].Values[0],
+	)
+}
@@ -0,0 +1,309 @@
+package influxql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Condition represents a boolean expression used in WHERE clauses.
+type Condition interface {
+	Builder
+
+	// negate returns the opposite condition, InfluxQL has no NOT operator.
+	negate() Condition
+}
+
+var negatedOperators = map[string]string{
+	"=":  "!=",
+	"!=": "=",
+	"<":  ">=",
+	">=": "<",
+	">":  "<=",
+	"<=": ">",
+	"=~": "!~",
+	"!~": "=~",
+}
+
+type comparison struct {
+	key   interface{}
+	op    string
+	value interface{}
+}
+
+var _ = Condition(&comparison{})
+
+// Build satisfies Builder.
+func (c *comparison) Build() (string, error) {
+	return c.buildWith(&buildContext{})
+}
+
+func (c *comparison) buildWith(ctx *buildContext) (string, error) {
+	key, err := buildKey(ctx, c.key)
+	if err != nil {
+		return "", err
+	}
+
+	operand, err := (&value{c.value}).buildWith(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return key + " " + c.op + " " + operand, nil
+}
+
+func (c *comparison) negate() Condition {
+	return &comparison{key: c.key, op: negatedOperators[c.op], value: c.value}
+}
+
+func buildKey(ctx *buildContext, key interface{}) (string, error) {
+	switch t := key.(type) {
+	case Builder:
+		return ctx.build(t)
+	case string:
+		return quoteIdent(t), nil
+	}
+	return "", fmt.Errorf("Unsupported condition key type %T.", key)
+}
+
+// Eq represents key = value.
+func Eq(key interface{}, value interface{}) Condition {
+	return &comparison{key: key, op: "=", value: value}
+}
+
+// Neq represents key != value.
+func Neq(key interface{}, value interface{}) Condition {
+	return &comparison{key: key, op: "!=", value: value}
+}
+
+// Lt represents key < value.
+func Lt(key interface{}, value interface{}) Condition {
+	return &comparison{key: key, op: "<", value: value}
+}
+
+// Lte represents key <= value.
+func Lte(key interface{}, value interface{}) Condition {
+	return &comparison{key: key, op: "<=", value: value}
+}
+
+// Gt represents key > value.
+func Gt(key interface{}, value interface{}) Condition {
+	return &comparison{key: key, op: ">", value: value}
+}
+
+// Gte represents key >= value.
+func Gte(key interface{}, value interface{}) Condition {
+	return &comparison{key: key, op: ">=", value: value}
+}
+
+// Match represents key =~ /pattern/.
+func Match(key interface{}, pattern Regex) Condition {
+	return &comparison{key: key, op: "=~", value: pattern}
+}
+
+// NotMatch represents key !~ /pattern/.
+func NotMatch(key interface{}, pattern Regex) Condition {
+	return &comparison{key: key, op: "!~", value: pattern}
+}
+
+type logical struct {
+	op    *keyword
+	conds []Condition
+}
+
+var _ = Condition(&logical{})
+
+// Build satisfies Builder.
+func (l *logical) Build() (string, error) {
+	return l.buildWith(&buildContext{})
+}
+
+func (l *logical) buildWith(ctx *buildContext) (string, error) {
+	if len(l.conds) == 0 {
+		return "", fmt.Errorf("Empty %s condition.", l.op.v)
+	}
+
+	parts := make([]string, 0, len(l.conds))
+	for _, cond := range l.conds {
+		s, err := ctx.build(cond)
+		if err != nil {
+			return "", err
+		}
+		// AND binds tighter than OR.
+		if inner := compound(cond); inner != nil && inner.op == orKeyword &&
+			l.op == andKeyword && len(inner.conds) > 1 {
+			s = "(" + s + ")"
+		}
+		parts = append(parts, s)
+	}
+
+	return strings.Join(parts, " "+l.op.v+" "), nil
+}
+
+func (l *logical) negate() Condition {
+	op := andKeyword
+	if l.op == andKeyword {
+		op = orKeyword
+	}
+
+	conds := make([]Condition, 0, len(l.conds))
+	for _, cond := range l.conds {
+		conds = append(conds, cond.negate())
+	}
+	return &logical{op: op, conds: conds}
+}
+
+// And represents a conjunction of conditions.
+func And(conds ...Condition) Condition {
+	return &logical{op: andKeyword, conds: conds}
+}
+
+// Or represents a disjunction of conditions.
+func Or(conds ...Condition) Condition {
+	return &logical{op: orKeyword, conds: conds}
+}
+
+// Not represents the negation of a condition. InfluxQL has no NOT operator,
+// so the condition is rewritten: comparison operators are inverted and AND
+// and OR are swapped according to De Morgan's laws.
+func Not(cond Condition) Condition {
+	return cond.negate()
+}
+
+// where creates a WHERE clause item either from an expression string with
+// values or from a Condition or another Builder like Raw.
+func where(expr interface{}, values []interface{}) Builder {
+	switch t := expr.(type) {
+	case string:
+		return &Expr{expr: t, values: values}
+	case Builder:
+		if len(values) > 0 {
+			return &invalid{errors.New("Unexpected values given along with a Condition.")}
+		}
+		return t
+	}
+	return &invalid{fmt.Errorf("Unsupported condition type %T.", expr)}
+}
+
+// appendConjunction adds a condition joined with AND, unless it is the first
+// one.
+func appendConjunction(conds []Builder, cond Builder) []Builder {
+	if len(conds) > 0 {
+		return append(conds, andKeyword, cond)
+	}
+	return append(conds, cond)
+}
+
+// appendDisjunction adds a condition joined with OR.
+func appendDisjunction(conds []Builder, cond Builder) []Builder {
+	if len(conds) > 0 {
+		return append(conds, orKeyword, cond)
+	}
+	return append(conds, cond)
+}
+
+// compileConditionsInto compiles a chain of conditions built with
+// Where/And/Or, groups and composite conditions are parenthesized when they
+// are part of a longer chain.
+func compileConditionsInto(ctx *buildContext, src []Builder, dst *[]string) error {
+	if err := compileArrayInto(ctx, src, dst); err != nil {
+		return err
+	}
+	if len(src) < 2 {
+		return nil
+	}
+	for i := range src {
+		if _, ok := src[i].(*ConditionGroup); ok {
+			(*dst)[i] = "(" + (*dst)[i] + ")"
+		} else if l := compound(src[i]); l != nil && len(l.conds) > 1 {
+			(*dst)[i] = "(" + (*dst)[i] + ")"
+		}
+	}
+	return nil
+}
+
+// compound returns the logical condition b is rendered as, if any.
+func compound(b Builder) *logical {
+	switch t := b.(type) {
+	case *logical:
+		return t
+	case *ListCondition:
+		if cond, err := t.expand(); err == nil {
+			l, _ := cond.(*logical)
+			return l
+		}
+	}
+	return nil
+}
+
+type invalid struct {
+	err error
+}
+
+// Build satisfies Builder.
+func (i *invalid) Build() (string, error) {
+	return "", i.err
+}
+
+// ConditionGroup is a chain of conditions rendered in parentheses when it is
+// joined with other conditions, it is created by the AndGroup and OrGroup
+// methods.
+type ConditionGroup struct {
+	where []Builder
+}
+
+func newConditionGroup(fn func(*ConditionGroup)) *ConditionGroup {
+	g := &ConditionGroup{}
+	fn(g)
+	return g
+}
+
+// Where replaces the conditions of the group. The expr is either a string
+// with optional values or a Condition.
+func (g *ConditionGroup) Where(expr interface{}, values ...interface{}) *ConditionGroup {
+	g.where = make([]Builder, 0, 1)
+	g.where = append(g.where, where(expr, values))
+	return g
+}
+
+// And adds a conjunction to the group.
+func (g *ConditionGroup) And(expr interface{}, values ...interface{}) *ConditionGroup {
+	g.where = appendConjunction(g.where, where(expr, values))
+	return g
+}
+
+// Or adds a disjunction to the group.
+func (g *ConditionGroup) Or(expr interface{}, values ...interface{}) *ConditionGroup {
+	g.where = appendDisjunction(g.where, where(expr, values))
+	return g
+}
+
+// AndGroup adds a nested group joined with AND.
+func (g *ConditionGroup) AndGroup(fn func(*ConditionGroup)) *ConditionGroup {
+	g.where = appendConjunction(g.where, newConditionGroup(fn))
+	return g
+}
+
+// OrGroup adds a nested group joined with OR.
+func (g *ConditionGroup) OrGroup(fn func(*ConditionGroup)) *ConditionGroup {
+	g.where = appendDisjunction(g.where, newConditionGroup(fn))
+	return g
+}
+
+// Build satisfies Builder.
+func (g *ConditionGroup) Build() (string, error) {
+	return g.buildWith(&buildContext{})
+}
+
+func (g *ConditionGroup) buildWith(ctx *buildContext) (string, error) {
+	if len(g.where) == 0 {
+		return "", errors.New("Empty condition group.")
+	}
+
+	var parts []string
+	if err := compileConditionsInto(ctx, g.where, &parts); err != nil {
+		return "", err
+	}
+
+	return joinWithSpace(parts), nil
+}
@@ -12,15 +12,16 @@ type CreateDatabaseBuilder struct {
 // CreateDatabase creates a SHOW query.
 func CreateDatabase(name string) *CreateDatabaseBuilder {
 	return &CreateDatabaseBuilder{
-		database: &literal{name},
+		database: Ident(name),
 	}
 }
 
 // Build satisfies Builder.
 func (s *CreateDatabaseBuilder) Build() (string, error) {
 	data := createDatabaseTemplateValues{}
+	ctx := &buildContext{}
 
-	if err := compileInto(s.database, &data.Database); err != nil {
+	if err := compileInto(ctx, s.database, &data.Database); err != nil {
 		return "", err
 	}
 
@@ -16,19 +16,24 @@ type CreateRetentionPolicyBuilder struct {
 	isAlter     bool
 }
 
-// CreateRetentionPolicy creates a CREATE|IsAlter RETENTION POLICY query.
+// CreateRetentionPolicy creates a CREATE|IsAlter RETENTION POLICY query. Pass
+// Infinite as duration to keep data forever.
 func CreateRetentionPolicy(
 	name string,
 	database string,
 	duration time.Duration,
 	replication int,
 ) *CreateRetentionPolicyBuilder {
-	return &CreateRetentionPolicyBuilder{
-		name:        &literal{name},
-		database:    &literal{database},
+	s := &CreateRetentionPolicyBuilder{
+		name:        Ident(name),
+		database:    Ident(database),
 		duration:    &value{duration},
 		replication: replication,
 	}
+	if duration == Infinite {
+		s.duration = &keyword{"INF"}
+	}
+	return s
 }
 
 // Shard represents SHARD DURATION "x"
@@ -57,20 +62,21 @@ func (s *CreateRetentionPolicyBuilder) Default() *CreateRetentionPolicyBuilder {
 // Build satisfies Builder
 func (s *CreateRetentionPolicyBuilder) Build() (string, error) {
 	data := createRetentionPolicyTemplateValues{}
+	ctx := &buildContext{}
 
-	if err := compileInto(s.name, &data.Name); err != nil {
+	if err := compileInto(ctx, s.name, &data.Name); err != nil {
 		return "", err
 	}
 
-	if err := compileInto(s.database, &data.Database); err != nil {
+	if err := compileInto(ctx, s.database, &data.Database); err != nil {
 		return "", err
 	}
 
-	if err := compileInto(s.duration, &data.Duration); err != nil {
+	if err := compileInto(ctx, s.duration, &data.Duration); err != nil {
 		return "", err
 	}
 	if s.shard != nil {
-		if err := compileInto(s.shard, &data.ShardDuration); err != nil {
+		if err := compileInto(ctx, s.shard, &data.ShardDuration); err != nil {
 			return "", err
 		}
 	}
@@ -0,0 +1,51 @@
+package influxql
+
+import (
+	"bytes"
+)
+
+// CreateUserBuilder represents a CREATE USER statement.
+type CreateUserBuilder struct {
+	name     Builder
+	password Builder
+	isAdmin  bool
+}
+
+// CreateUser creates a CREATE USER query. The password is rendered as an
+// escaped string literal, so it may contain quotes and backslashes.
+func CreateUser(name string, password string) *CreateUserBuilder {
+	return &CreateUserBuilder{
+		name:     Ident(name),
+		password: &value{password},
+	}
+}
+
+// Admin adds WITH ALL PRIVILEGES.
+func (s *CreateUserBuilder) Admin() *CreateUserBuilder {
+	s.isAdmin = true
+
+	return s
+}
+
+// Build satisfies Builder.
+func (s *CreateUserBuilder) Build() (string, error) {
+	data := createUserTemplateValues{}
+	ctx := &buildContext{}
+
+	if err := compileInto(ctx, s.name, &data.Name); err != nil {
+		return "", err
+	}
+
+	if err := compileInto(ctx, s.password, &data.Password); err != nil {
+		return "", err
+	}
+
+	data.IsAdmin = s.isAdmin
+
+	buf := bytes.NewBuffer(nil)
+	err := createUserTemplate.Execute(buf, data)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,214 @@
+package influxql
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DecodeCSVResponse decodes a /query response in the application/csv format
+// into the same model as DecodeResponse. The epoch is the precision the query
+// was sent with, times are epoch integers in CSV.
+//
+// The results of statements are separated by a blank line, within a result
+// the header line is repeated when the columns change. CSV has no statement
+// ids and statements that return no series are not written at all, so
+// results are numbered in order of appearance and only match the statement
+// ids when every statement returns series. CSV has no types either: values
+// are parsed as numbers or booleans when they look like ones, so a string
+// field holding "1" is decoded as an integer.
+func DecodeCSVResponse(r io.Reader, epoch Precision) (*Response, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	resp := &Response{Results: []*Result{}}
+
+	var (
+		columns []string
+		result  *Result
+		series  *Series
+		// end is the last line of the previous record, the reader skips
+		// blank lines so they are found by a gap in the line numbers.
+		end int
+	)
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return resp, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Unable to decode CSV response: %v.", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		separated := result == nil || line > end+1
+		end = recordEnd(reader, record)
+
+		if separated {
+			columns = nil
+		}
+
+		switch {
+		case len(record) == 1 && record[0] == "error":
+			message, err := reader.Read()
+			if err != nil || len(message) != 1 {
+				return nil, fmt.Errorf(
+					"Unable to decode CSV response: missing error message on line %d.",
+					line+1,
+				)
+			}
+			end = recordEnd(reader, message)
+
+			if separated {
+				result = &Result{StatementID: len(resp.Results)}
+				resp.Results = append(resp.Results, result)
+			}
+			result.Err = message[0]
+			continue
+
+		case len(record) >= 2 && record[0] == "name" && record[1] == "tags":
+			if separated {
+				result = &Result{StatementID: len(resp.Results)}
+				resp.Results = append(resp.Results, result)
+			}
+			columns = record[2:]
+			series = nil
+			continue
+		}
+
+		if columns == nil {
+			return nil, fmt.Errorf(
+				"Unable to decode CSV response: missing header on line %d.", line,
+			)
+		}
+		if len(record) != len(columns)+2 {
+			return nil, fmt.Errorf(
+				"Unable to decode CSV response: line %d has %d values, expected %d.",
+				line, len(record), len(columns)+2,
+			)
+		}
+
+		tags, err := parseTagKey(record[1])
+		if err != nil {
+			return nil, fmt.Errorf(
+				"Unable to decode CSV response: line %d: %v.", line, err,
+			)
+		}
+
+		if series == nil || series.Name != record[0] || !equalTags(series.Tags, tags) {
+			series = &Series{Name: record[0], Tags: tags, Columns: columns}
+			result.Series = append(result.Series, series)
+		}
+
+		values := make([]interface{}, 0, len(columns))
+		for i, field := range record[2:] {
+			v, err := parseCSVValue(field, columns[i] == "time", epoch)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"Invalid value of column %q in series %q: %v.",
+					columns[i], series.Name, err,
+				)
+			}
+			values = append(values, v)
+		}
+		series.Values = append(series.Values, values)
+	}
+}
+
+// recordEnd returns the line the record just read ends on, quoted fields
+// may span several lines.
+func recordEnd(reader *csv.Reader, record []string) int {
+	last := len(record) - 1
+	line, _ := reader.FieldPos(last)
+	return line + strings.Count(record[last], "\n")
+}
+
+func parseCSVValue(field string, isTime bool, epoch Precision) (interface{}, error) {
+	if field == "" {
+		return nil, nil
+	}
+	if isTime {
+		n, err := parseNumber(field)
+		if err != nil {
+			return nil, err
+		}
+		return parseTime(n, epoch)
+	}
+
+	switch field {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	if looksNumeric(field) {
+		if n, err := parseNumber(field); err == nil {
+			return n, nil
+		}
+	}
+	return field, nil
+}
+
+func looksNumeric(s string) bool {
+	if s[0] == '-' || s[0] == '+' {
+		s = s[1:]
+	}
+	return s != "" && ('0' <= s[0] && s[0] <= '9' || s[0] == '.')
+}
+
+// parseTagKey parses tags formatted as k1=v1,k2=v2 where commas, equal signs
+// and spaces are escaped with a backslash.
+func parseTagKey(s string) (map[string]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	tags := map[string]string{}
+	var (
+		key     string
+		current strings.Builder
+		inValue bool
+	)
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte(",= ", s[i+1]) >= 0:
+			i++
+			current.WriteByte(s[i])
+		case c == '=' && !inValue:
+			key = current.String()
+			current.Reset()
+			inValue = true
+		case c == ',':
+			if !inValue {
+				return nil, fmt.Errorf("missing value of tag %q", current.String())
+			}
+			tags[key] = current.String()
+			current.Reset()
+			inValue = false
+		default:
+			current.WriteByte(c)
+		}
+	}
+	if !inValue {
+		return nil, fmt.Errorf("missing value of tag %q", current.String())
+	}
+	tags[key] = current.String()
+
+	return tags, nil
+}
+
+func equalTags(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if tag, ok := b[k]; !ok || tag != v {
+			return false
+		}
+	}
+	return true
+}
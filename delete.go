@@ -9,6 +9,7 @@ type DeleteBuilder struct {
 	measurement Builder
 	retention   Builder
 	where       []Builder
+	epoch       Precision
 }
 
 // Delete creates a DELETE query.
@@ -17,44 +18,75 @@ func Delete() *DeleteBuilder {
 	return builder
 }
 
-// From represents the FROM in DELETE x FROM.
-func (builder *DeleteBuilder) From(measurement string) *DeleteBuilder {
+// From represents the FROM in DELETE x FROM, the measurement is either a name
+// or a Regex.
+func (builder *DeleteBuilder) From(measurement interface{}) *DeleteBuilder {
 	builder.measurement = &literal{measurement}
 	return builder
 }
 
-// Where replaces the current conditions.
-func (builder *DeleteBuilder) Where(expr string, values ...interface{}) *DeleteBuilder {
+// Where replaces the current conditions. The expr is either a string with
+// optional values or a Condition.
+func (builder *DeleteBuilder) Where(expr interface{}, values ...interface{}) *DeleteBuilder {
 	builder.where = make([]Builder, 0, 1)
-	builder.where = append(builder.where, &Expr{expr: expr, values: values})
+	builder.where = append(builder.where, where(expr, values))
 	return builder
 }
 
 // And adds a conjunction to the list of conditions.
-func (builder *DeleteBuilder) And(expr string, values ...interface{}) *DeleteBuilder {
-	if len(builder.where) > 0 {
-		builder.where = append(builder.where, andKeyword, &Expr{expr: expr, values: values})
-	} else {
-		builder.where = append(builder.where, &Expr{expr: expr, values: values})
-	}
+func (builder *DeleteBuilder) And(expr interface{}, values ...interface{}) *DeleteBuilder {
+	builder.where = appendConjunction(builder.where, where(expr, values))
 	return builder
 }
 
 // Or adds a disjunction to the list of conditions.
-func (builder *DeleteBuilder) Or(expr string, values ...interface{}) *DeleteBuilder {
-	builder.where = append(builder.where, orKeyword, &Expr{expr: expr, values: values})
+func (builder *DeleteBuilder) Or(expr interface{}, values ...interface{}) *DeleteBuilder {
+	builder.where = appendDisjunction(builder.where, where(expr, values))
+	return builder
+}
+
+// Epoch renders times in conditions as epoch integers with the given
+// precision instead of RFC3339 strings.
+func (builder *DeleteBuilder) Epoch(precision Precision) *DeleteBuilder {
+	builder.epoch = precision
+	return builder
+}
+
+// AndGroup adds a parenthesized group of conditions joined with AND.
+func (builder *DeleteBuilder) AndGroup(fn func(*ConditionGroup)) *DeleteBuilder {
+	builder.where = appendConjunction(builder.where, newConditionGroup(fn))
+	return builder
+}
+
+// OrGroup adds a parenthesized group of conditions joined with OR.
+func (builder *DeleteBuilder) OrGroup(fn func(*ConditionGroup)) *DeleteBuilder {
+	builder.where = appendDisjunction(builder.where, newConditionGroup(fn))
 	return builder
 }
 
 // Build satisfies Builder.
 func (builder *DeleteBuilder) Build() (string, error) {
+	return builder.buildWith(&buildContext{epoch: builder.epoch})
+}
+
+// BuildParams satisfies ParamsBuilder.
+func (builder *DeleteBuilder) BuildParams() (string, map[string]interface{}, error) {
+	ctx := &buildContext{epoch: builder.epoch, params: map[string]interface{}{}}
+	q, err := builder.buildWith(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	return q, ctx.params, nil
+}
+
+func (builder *DeleteBuilder) buildWith(ctx *buildContext) (string, error) {
 	data := deleteTemplateValues{}
 
-	if err := compileInto(builder.measurement, &data.Measurement); err != nil {
+	if err := compileInto(ctx, builder.measurement, &data.Measurement); err != nil {
 		return "", err
 	}
 
-	if err := compileArrayInto(builder.where, &data.Where); err != nil {
+	if err := compileConditionsInto(ctx, builder.where, &data.Where); err != nil {
 		return "", err
 	}
 
@@ -0,0 +1,257 @@
+package influxql
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const placeholder = '?'
+
+// Expr represents an expression.
+//
+// Values are bound to placeholders: ? takes the next positional value while
+// :name and @name take a named value from a map[string]interface{} or a
+// struct passed as the only value. Struct fields are matched by their
+// `influx` tag or by their name. Placeholders inside string literals, quoted
+// identifiers and regexes are left as is.
+type Expr struct {
+	expr   string
+	values []interface{}
+}
+
+// exprToken is either text copied as is or a placeholder.
+type exprToken struct {
+	text        string
+	placeholder bool
+	name        string
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || ('0' <= c && c <= '9')
+}
+
+// scanQuoted returns the index right after the literal starting at i and
+// closed by quote, backslash escapes are skipped.
+func scanQuoted(s string, i int, quote byte) (int, error) {
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case quote:
+			return j + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("Unterminated literal in expression %q.", s)
+}
+
+// isRegexStart tells whether a / at i starts a regex, which is the case
+// after the =~ and !~ operators.
+func isRegexStart(s string, i int) bool {
+	prev := strings.TrimRight(s[:i], " \t\r\n")
+	return strings.HasSuffix(prev, "=~") || strings.HasSuffix(prev, "!~")
+}
+
+// scanExpr splits an expression into text and placeholders.
+func scanExpr(s string) ([]exprToken, error) {
+	var (
+		tokens []exprToken
+		start  int
+	)
+
+	flush := func(end int) {
+		if end > start {
+			tokens = append(tokens, exprToken{text: s[start:end]})
+		}
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\'' || c == '"' || (c == '/' && isRegexStart(s, i)):
+			end, err := scanQuoted(s, i, c)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+
+		case c == ':' && i+1 < len(s) && s[i+1] == ':':
+			// Type cast, like "host"::tag.
+			i += 2
+			for i < len(s) && isNameChar(s[i]) {
+				i++
+			}
+
+		case c == placeholder:
+			flush(i)
+			tokens = append(tokens, exprToken{placeholder: true})
+			i++
+			start = i
+
+		case (c == ':' || c == '@') && i+1 < len(s) && isNameStart(s[i+1]):
+			flush(i)
+			end := i + 1
+			for end < len(s) && isNameChar(s[end]) {
+				end++
+			}
+			tokens = append(tokens, exprToken{placeholder: true, name: s[i+1 : end]})
+			i = end
+			start = i
+
+		default:
+			i++
+		}
+	}
+	flush(len(s))
+
+	return tokens, nil
+}
+
+// namedValue looks up a named value in a map or a struct.
+func namedValue(values interface{}, name string) (interface{}, bool) {
+	if m, ok := values.(map[string]interface{}); ok {
+		v, ok := m[name]
+		return v, ok
+	}
+
+	rv := reflect.ValueOf(values)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		v := rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()))
+		if !v.IsValid() {
+			return nil, false
+		}
+		return v.Interface(), true
+
+	case reflect.Struct:
+		t := rv.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			key := strings.Split(field.Tag.Get("influx"), ",")[0]
+			if key == "-" {
+				continue
+			}
+			if key == "" {
+				key = field.Name
+			}
+			if key == name {
+				return rv.Field(i).Interface(), true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// Build satisfies Builder.
+func (e *Expr) Build() (string, error) {
+	return e.buildWith(&buildContext{})
+}
+
+func (e *Expr) buildWith(ctx *buildContext) (string, error) {
+	tokens, err := scanExpr(e.expr)
+	if err != nil {
+		return "", err
+	}
+
+	var positional, named int
+	for _, token := range tokens {
+		switch {
+		case token.name != "":
+			named++
+		case token.placeholder:
+			positional++
+		}
+	}
+
+	switch {
+	case positional > 0 && named > 0:
+		return "", fmt.Errorf("Mixed positional and named placeholders in %q.", e.expr)
+
+	case named > 0:
+		// Where("foo = :foo", map[string]interface{}{"foo": "bar"})
+		if len(e.values) != 1 {
+			return "", fmt.Errorf(
+				"Expecting a map or a struct with named values, got %d values.",
+				len(e.values),
+			)
+		}
+
+	case positional > 0:
+		// Where("foo = ?", "bar")
+		if positional != len(e.values) {
+			return "", fmt.Errorf(
+				"Mismatched number of placeholders (%d) and values (%d)",
+				positional,
+				len(e.values),
+			)
+		}
+
+	case len(e.values) > 0:
+		parts := strings.Fields(e.expr)
+		lparts := len(parts)
+
+		if lparts < 1 {
+			return "", fmt.Errorf("Expecting statement.")
+		} else if lparts < 2 {
+			// Where("foo", "bar")
+			if len(e.values) != 1 {
+				return "", fmt.Errorf("Expecting exactly one value.")
+			}
+			tokens = []exprToken{{text: quoteIdent(parts[0]) + " = "}, {placeholder: true}}
+		} else if lparts < 3 {
+			// Where("foo =", "bar")
+			if len(e.values) != 1 {
+				return "", fmt.Errorf("Expecting exactly one value.")
+			}
+			tokens = []exprToken{{text: quoteIdent(parts[0]) + " " + parts[1] + " "}, {placeholder: true}}
+		} else {
+			return "", fmt.Errorf("Unsupported expression %q", e.expr)
+		}
+	}
+
+	var (
+		buf  strings.Builder
+		next int
+	)
+	for _, token := range tokens {
+		if !token.placeholder {
+			buf.WriteString(token.text)
+			continue
+		}
+
+		var v interface{}
+		if token.name != "" {
+			var ok bool
+			v, ok = namedValue(e.values[0], token.name)
+			if !ok {
+				return "", fmt.Errorf("Missing value for placeholder %q.", token.name)
+			}
+		} else {
+			v = e.values[next]
+			next++
+		}
+
+		s, err := (&value{v}).buildWith(ctx)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(s)
+	}
+
+	return buf.String(), nil
+}
@@ -2,6 +2,7 @@ package influxql
 
 import (
 	"fmt"
+	"math"
 	"strings"
 	"time"
 )
@@ -12,19 +13,47 @@ type timeGroup struct {
 
 var _ = Builder(&timeGroup{})
 
+// Infinite is a duration that never ends, it is rendered as INF by
+// CreateRetentionPolicy.
+const Infinite = time.Duration(math.MaxInt64)
+
+var durationUnits = []struct {
+	unit   uint64
+	suffix string
+}{
+	{uint64(7 * 24 * time.Hour), "w"},
+	{uint64(24 * time.Hour), "d"},
+	{uint64(time.Hour), "h"},
+	{uint64(time.Minute), "m"},
+	{uint64(time.Second), "s"},
+	{uint64(time.Millisecond), "ms"},
+	{uint64(time.Microsecond), "u"},
+	{uint64(time.Nanosecond), "ns"},
+}
+
+// timeFormat returns in as an InfluxQL duration literal, it is split into
+// units from weeks down to nanoseconds, like 1h30m or 1s500ms.
 func timeFormat(in time.Duration) string {
-	ns := in.Nanoseconds()
-
-	switch int64(0) {
-	case ns % int64(time.Hour):
-		return fmt.Sprintf("%dh", ns/int64(time.Hour))
-	case ns % int64(time.Minute):
-		return fmt.Sprintf("%dm", ns/int64(time.Minute))
-	case ns % int64(time.Second):
-		return fmt.Sprintf("%ds", ns/int64(time.Second))
+	if in == 0 {
+		return "0s"
 	}
 
-	return fmt.Sprintf("%dns", ns)
+	sign := ""
+	ns := uint64(in)
+	if in < 0 {
+		sign = "-"
+		ns = uint64(-(in + 1)) + 1
+	}
+
+	parts := []string{sign}
+	for _, u := range durationUnits {
+		if ns >= u.unit {
+			parts = append(parts, fmt.Sprintf("%d%s", ns/u.unit, u.suffix))
+			ns %= u.unit
+		}
+	}
+
+	return strings.Join(parts, "")
 }
 
 // Build satisfies Builder.
@@ -37,6 +66,11 @@ func Time(duration time.Duration) Builder {
 	return &timeGroup{d: duration}
 }
 
+// Field represents a field or tag key identifier passed to Func.
+func Field(name string) Builder {
+	return Ident(name)
+}
+
 // F represents a function.
 type F struct {
 	name  string
@@ -52,32 +86,37 @@ func (f *F) As(alias string) *F {
 
 // Build satisfies Builder.
 func (f *F) Build() (string, error) {
+	return f.buildWith(&buildContext{})
+}
+
+func (f *F) buildWith(ctx *buildContext) (string, error) {
 	if f.name == "" {
 		return "", fmt.Errorf("Missing function name.")
 	}
+	if spec, ok := LookupFunc(f.name); ok {
+		if err := spec.validate(f.args); err != nil {
+			return "", err
+		}
+	}
 	args := make([]string, 0, len(f.args))
 	for _, arg := range f.args {
-		var s string
-		switch t := arg.(type) {
-		case Builder:
-			var err error
-			s, err = t.Build()
-			if err != nil {
-				return "", err
-			}
-		default:
-			s = fmt.Sprintf("%v", t)
+		s, err := (&value{arg}).buildWith(ctx)
+		if err != nil {
+			return "", err
 		}
 		args = append(args, s)
 	}
 	fn := fmt.Sprintf("%s(%s)", f.name, strings.Join(args, ", "))
 	if f.alias != "" {
-		fn = fmt.Sprintf("%s AS %q", fn, f.alias)
+		fn = fn + " AS " + quoteIdent(f.alias)
 	}
 	return fn, nil
 }
 
-// Func creates a function.
+// Func creates a function. Arguments are rendered by their type: a Builder
+// is compiled, durations become duration literals, strings become string
+// literals and numbers are written as is. Use Field to pass an identifier and
+// Regex to pass a regular expression.
 func Func(name string, args ...interface{}) *F {
 	return &F{name: name, args: args}
 }
@@ -107,24 +146,38 @@ func Sum(field interface{}) *F {
 	return Func("SUM", []interface{}{&literal{field}}...)
 }
 
-// Bottom represents the BOTTOM function.
+// selectorArgs treats string params of TOP and BOTTOM as tag keys.
+func selectorArgs(field interface{}, params []interface{}) []interface{} {
+	args := []interface{}{&literal{field}}
+	for _, param := range params {
+		if tag, ok := param.(string); ok {
+			param = &literal{tag}
+		}
+		args = append(args, param)
+	}
+	return args
+}
+
+// Bottom represents the BOTTOM function, params are optional tag keys
+// followed by the number of values to return.
 func Bottom(field interface{}, params ...interface{}) *F {
-	return Func("BOTTOM", append([]interface{}{&literal{field}}, params...))
+	return Func("BOTTOM", selectorArgs(field, params)...)
 }
 
-// Top represents the TOP function.
+// Top represents the TOP function, params are optional tag keys followed by
+// the number of values to return.
 func Top(field interface{}, params ...interface{}) *F {
-	return Func("TOP", append([]interface{}{&literal{field}}, params...))
+	return Func("TOP", selectorArgs(field, params)...)
 }
 
 // Derivative represents the DERIVATIVE function.
 func Derivative(field interface{}, params ...interface{}) *F {
-	return Func("DERIVATIVE", append([]interface{}{&literal{field}}, params...))
+	return Func("DERIVATIVE", append([]interface{}{&literal{field}}, params...)...)
 }
 
 // NonNegativeDerivative represents the NON_NEGATIVE_DERIVATIVE function.
 func NonNegativeDerivative(field interface{}, params ...interface{}) *F {
-	return Func("NON_NEGATIVE_DERIVATIVE", append([]interface{}{&literal{field}}, params...))
+	return Func("NON_NEGATIVE_DERIVATIVE", append([]interface{}{&literal{field}}, params...)...)
 }
 
 // First represents the FIRST function.
@@ -159,5 +212,5 @@ func Distinct(field interface{}) *F {
 
 // Percentile represents the PERCENTILE function.
 func Percentile(field interface{}, p float64) *F {
-	return Func("PERCENTILE", []interface{}{&literal{field}, p})
+	return Func("PERCENTILE", &literal{field}, p)
 }
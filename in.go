@@ -0,0 +1,165 @@
+package influxql
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// InStrategy tells how In and NotIn conditions are expanded, InfluxQL has no
+// IN operator.
+type InStrategy int
+
+// Expansion strategies.
+const (
+	// InAuto uses InRegex for lists of strings longer than
+	// inRegexThreshold and InDisjunction otherwise.
+	InAuto InStrategy = iota
+	// InDisjunction expands to key = a OR key = b, or to key != a AND
+	// key != b for NotIn.
+	InDisjunction
+	// InRegex expands to key =~ /^(?:a|b)$/, or to key !~ /^(?:a|b)$/ for
+	// NotIn. Only string values can be matched by a regex.
+	InRegex
+)
+
+// inRegexThreshold is the number of values above which InAuto switches to a
+// regex, long OR chains are slow to parse and plan.
+const inRegexThreshold = 16
+
+// ListCondition represents an In or NotIn condition.
+type ListCondition struct {
+	key      interface{}
+	values   []interface{}
+	negated  bool
+	strategy InStrategy
+}
+
+var _ = Condition(&ListCondition{})
+
+// In represents a condition that is true when key equals any of the values.
+// A single slice argument is expanded into values, duplicates are removed.
+func In(key interface{}, values ...interface{}) *ListCondition {
+	return &ListCondition{key: key, values: listValues(values)}
+}
+
+// NotIn represents a condition that is true when key equals none of the
+// values.
+func NotIn(key interface{}, values ...interface{}) *ListCondition {
+	return &ListCondition{key: key, values: listValues(values), negated: true}
+}
+
+// Strategy sets how the condition is expanded.
+func (c *ListCondition) Strategy(strategy InStrategy) *ListCondition {
+	c.strategy = strategy
+	return c
+}
+
+func listValues(values []interface{}) []interface{} {
+	if len(values) == 1 {
+		v := reflect.ValueOf(values[0])
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+			values = make([]interface{}, 0, v.Len())
+			for i := 0; i < v.Len(); i++ {
+				values = append(values, v.Index(i).Interface())
+			}
+		}
+	}
+
+	seen := map[interface{}]bool{}
+	unique := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		if value != nil && reflect.TypeOf(value).Comparable() {
+			if seen[value] {
+				continue
+			}
+			seen[value] = true
+		}
+		unique = append(unique, value)
+	}
+	return unique
+}
+
+func (c *ListCondition) stringValues() ([]string, bool) {
+	values := make([]string, 0, len(c.values))
+	for _, value := range c.values {
+		s, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		values = append(values, s)
+	}
+	return values, true
+}
+
+// expand returns the condition the list is rewritten to.
+func (c *ListCondition) expand() (Condition, error) {
+	if len(c.values) == 0 {
+		return nil, errors.New("Empty list of values.")
+	}
+
+	texts, isStrings := c.stringValues()
+
+	strategy := c.strategy
+	if strategy == InAuto {
+		strategy = InDisjunction
+		if isStrings && len(texts) > inRegexThreshold {
+			strategy = InRegex
+		}
+	}
+
+	switch strategy {
+	case InDisjunction:
+		if len(c.values) == 1 {
+			return c.compare(c.values[0]), nil
+		}
+		conds := make([]Condition, 0, len(c.values))
+		for _, value := range c.values {
+			conds = append(conds, c.compare(value))
+		}
+		if c.negated {
+			return And(conds...), nil
+		}
+		return Or(conds...), nil
+
+	case InRegex:
+		if !isStrings {
+			return nil, errors.New("Only strings can be matched with a regex.")
+		}
+		if c.negated {
+			return NotMatch(c.key, QuoteMeta(texts...)), nil
+		}
+		return Match(c.key, QuoteMeta(texts...)), nil
+	}
+
+	return nil, fmt.Errorf("Unknown IN strategy %d.", strategy)
+}
+
+func (c *ListCondition) compare(value interface{}) Condition {
+	if c.negated {
+		return Neq(c.key, value)
+	}
+	return Eq(c.key, value)
+}
+
+// Build satisfies Builder.
+func (c *ListCondition) Build() (string, error) {
+	return c.buildWith(&buildContext{})
+}
+
+func (c *ListCondition) buildWith(ctx *buildContext) (string, error) {
+	cond, err := c.expand()
+	if err != nil {
+		return "", err
+	}
+	return ctx.build(cond)
+}
+
+func (c *ListCondition) negate() Condition {
+	return &ListCondition{
+		key:      c.key,
+		values:   c.values,
+		negated:  !c.negated,
+		strategy: c.strategy,
+	}
+}
@@ -4,3 +4,12 @@ package influxql
 type Builder interface {
 	Build() (string, error)
 }
+
+// ParamsBuilder represents a statement that can be compiled into InfluxQL with
+// values replaced by $p1, $p2, ... bind parameters. The parameters are sent
+// to InfluxDB along with the query in the params argument of /query, so
+// values are never interpolated into the query text.
+type ParamsBuilder interface {
+	Builder
+	BuildParams() (string, map[string]interface{}, error)
+}
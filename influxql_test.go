@@ -1,12 +1,30 @@
 package influxql // import "github.com/reconquest/influxql"
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
+type testState int
+
+type testHost string
+
+type testID int
+
+func (id testID) InfluxQLValue() (interface{}, error) {
+	if id < 0 {
+		return nil, errors.New("negative ID")
+	}
+	return fmt.Sprintf("id-%d", int(id)), nil
+}
+
 var testSamples = []struct {
 	b Builder
 	s string
@@ -227,6 +245,543 @@ var testSamples = []struct {
 		`CREATE RETENTION POLICY "name" ON "db" DURATION 1h REPLICATION 1 DEFAULT`,
 		false,
 	},
+	{
+		Select(Top("water_level", "location", 2)).From("h2o_feet"),
+		`SELECT TOP("water_level", "location", 2) FROM "h2o_feet"`,
+		false,
+	},
+	{
+		Select(Bottom("water_level", 3)).From("h2o_feet"),
+		`SELECT BOTTOM("water_level", 3) FROM "h2o_feet"`,
+		false,
+	},
+	{
+		Select(Percentile("water_level", 95)).From("h2o_feet"),
+		`SELECT PERCENTILE("water_level", 95) FROM "h2o_feet"`,
+		false,
+	},
+	{
+		Select(Top("water_level")).From("h2o_feet"),
+		``,
+		true, // Missing N.
+	},
+	{
+		Select(Top("water_level", "location")).From("h2o_feet"),
+		``,
+		true, // N must be an integer.
+	},
+	{
+		Select(Func("mean", Field("water_level"), 1)).From("h2o_feet"),
+		``,
+		true, // Too many arguments.
+	},
+	{
+		Select(Func("MY_FUNCTION", Field("water_level"), 1)).From("h2o_feet"),
+		`SELECT MY_FUNCTION("water_level", 1) FROM "h2o_feet"`,
+		false, // Unknown functions are not validated.
+	},
+	{
+		Select(Derivative(Mean("value"), time.Minute)).From("cpu"),
+		``,
+		true, // DERIVATIVE of an aggregation requires GROUP BY time().
+	},
+	{
+		Select(Derivative(Mean("value"))).From("cpu").GroupBy(Time(time.Minute)),
+		`SELECT DERIVATIVE(MEAN("value")) FROM "cpu" GROUP BY time(1m)`,
+		false,
+	},
+	{
+		Select(Derivative("value")).From("cpu"),
+		`SELECT DERIVATIVE("value") FROM "cpu"`,
+		false,
+	},
+	{
+		Select("host", Mean("value")).From("cpu"),
+		``,
+		true, // Raw fields mixed with aggregations.
+	},
+	{
+		Select("host", Max("value")).From("cpu"),
+		`SELECT "host", MAX("value") FROM "cpu"`,
+		false,
+	},
+	{
+		Select(Mean(Max("value"))).From("cpu").GroupBy(Time(time.Minute)),
+		``,
+		true, // Aggregation nested in an aggregation.
+	},
+	{
+		Select(Func("HOLT_WINTERS", First("value"), 10, 4)).From("cpu"),
+		``,
+		true, // HOLT_WINTERS requires GROUP BY time().
+	},
+	{
+		Select(Derivative("value", time.Minute)).From("cpu"),
+		`SELECT DERIVATIVE("value", 1m) FROM "cpu"`,
+		false,
+	},
+	{
+		Select(Percentile("value", 99.9)).From("cpu"),
+		`SELECT PERCENTILE("value", 99.9) FROM "cpu"`,
+		false,
+	},
+	{
+		Select(Mean(Regex("^usage_"))).From("cpu"),
+		`SELECT MEAN(/^usage_/) FROM "cpu"`,
+		false,
+	},
+	{
+		Select(Func("EXPONENTIAL_MOVING_AVERAGE", Field("value"), 2, 0, "exponential")).From("cpu"),
+		`SELECT EXPONENTIAL_MOVING_AVERAGE("value", 2, 0, 'exponential') FROM "cpu"`,
+		false,
+	},
+	{
+		Select(Func("MY_FUNCTION", Field("value"), "it's", 1.5, true)).From("cpu"),
+		`SELECT MY_FUNCTION("value", 'it\'s', 1.5, true) FROM "cpu"`,
+		false,
+	},
+	{
+		Select(Func("MEAN", "value")).From("cpu"),
+		``,
+		true, // Strings are string literals, use Field for identifiers.
+	},
+	{
+		Select("foo").From("bar").Where(Eq("location", "Toronto")),
+		`SELECT "foo" FROM "bar" WHERE "location" = 'Toronto'`,
+		false,
+	},
+	{
+		Select("foo").From("bar").Where(And(Eq("location", "Toronto"), Or(Gt("altitude", 500), Lte("depth", 10)))),
+		`SELECT "foo" FROM "bar" WHERE "location" = 'Toronto' AND ("altitude" > 500 OR "depth" <= 10)`,
+		false,
+	},
+	{
+		Select("foo").From("bar").Where(Or(Eq("location", "Toronto"), And(Gte("altitude", 500), Lt("depth", 10)))),
+		`SELECT "foo" FROM "bar" WHERE "location" = 'Toronto' OR "altitude" >= 500 AND "depth" < 10`,
+		false,
+	},
+	{
+		Select("foo").From("bar").Where(Or(Eq("a", 1), Eq("b", 2))).And(Neq("c", "it's")),
+		`SELECT "foo" FROM "bar" WHERE ("a" = 1 OR "b" = 2) AND "c" != 'it\'s'`,
+		false,
+	},
+	{
+		Select("foo").From("bar").Where(Match("host", "^web/[0-9]+$")).And(NotMatch("region", "west")),
+		`SELECT "foo" FROM "bar" WHERE "host" =~ /^web\/[0-9]+$/ AND "region" !~ /west/`,
+		false,
+	},
+	{
+		Select("foo").From("bar").Where(Not(And(Eq("a", 1), Or(Lt("b", 2), Match("c", "x"))))),
+		`SELECT "foo" FROM "bar" WHERE "a" != 1 OR "b" >= 2 AND "c" !~ /x/`,
+		false,
+	},
+	{
+		Select("foo").From("bar").Where(Eq("cpu.load", 1)).Or(`"host" = ?`, "a"),
+		`SELECT "foo" FROM "bar" WHERE "cpu.load" = 1 OR "host" = 'a'`,
+		false,
+	},
+	{
+		Select("foo").From("bar").Where(Eq("a", 1), 2),
+		``,
+		true, // Values are not allowed along with a Condition.
+	},
+	{
+		Select("foo").From("bar").Where(And()),
+		``,
+		true, // Empty condition.
+	},
+	{
+		Delete().From("bar").Where(Eq("host", "a")).And(Lt("time", time.Date(2015, 8, 18, 0, 0, 0, 0, time.UTC))),
+		`DELETE FROM "bar" WHERE "host" = 'a' AND "time" < '2015-08-18T00:00:00Z'`,
+		false,
+	},
+	{
+		ShowTagKeys().From("bar").Where(Eq("host", "a")),
+		`SHOW TAG KEYS FROM "bar" WHERE "host" = 'a'`,
+		false,
+	},
+	{
+		ShowMeasurements().Where(Eq("host", "a")).Or("region = ?", "west"),
+		`SHOW MEASUREMENTS WHERE "host" = 'a' OR region = 'west'`,
+		false,
+	},
+	{
+		Select("foo").From("bar").Where("a = ?", 1).AndGroup(func(g *ConditionGroup) {
+			g.Where("b = ?", 2).Or("c = ?", 3)
+		}),
+		`SELECT "foo" FROM "bar" WHERE a = 1 AND (b = 2 OR c = 3)`,
+		false,
+	},
+	{
+		Select("foo").From("bar").Where("a = ?", 1).OrGroup(func(g *ConditionGroup) {
+			g.Where(Eq("b", 2)).And("c = ?", 3).OrGroup(func(g *ConditionGroup) {
+				g.Where("d = ?", 4).And(Or(Eq("e", 5), Eq("f", 6)))
+			})
+		}),
+		`SELECT "foo" FROM "bar" WHERE a = 1 OR ("b" = 2 AND c = 3 OR (d = 4 AND ("e" = 5 OR "f" = 6)))`,
+		false,
+	},
+	{
+		Select("foo").From("bar").AndGroup(func(g *ConditionGroup) {
+			g.Where("a = ?", 1)
+		}),
+		`SELECT "foo" FROM "bar" WHERE a = 1`,
+		false,
+	},
+	{
+		Select("foo").From("bar").Where(Eq("a", 1)).AndGroup(func(g *ConditionGroup) {
+			g.Where(Or(Eq("x", 1), Eq("y", 2)))
+		}),
+		`SELECT "foo" FROM "bar" WHERE "a" = 1 AND ("x" = 1 OR "y" = 2)`,
+		false,
+	},
+	{
+		Select("foo").From("bar").Where(Eq("a", 1)).AndGroup(func(g *ConditionGroup) {
+			g.Where("x = 1 OR y = 2")
+		}),
+		`SELECT "foo" FROM "bar" WHERE "a" = 1 AND (x = 1 OR y = 2)`,
+		false,
+	},
+	{
+		Select("foo").From("bar").Where("a = ?", 1).AndGroup(func(g *ConditionGroup) {}),
+		``,
+		true, // Empty group.
+	},
+	{
+		Delete().From("bar").Where("a = ?", 1).AndGroup(func(g *ConditionGroup) {
+			g.Where("b = ?", 2).Or("c = ?", 3)
+		}),
+		`DELETE FROM "bar" WHERE a = 1 AND (b = 2 OR c = 3)`,
+		false,
+	},
+	{
+		Select("foo").From("bar").Where(In("host", "a", "b", "a")).And(Eq("region", "west")),
+		`SELECT "foo" FROM "bar" WHERE ("host" = 'a' OR "host" = 'b') AND "region" = 'west'`,
+		false,
+	},
+	{
+		Select("foo").From("bar").Where(NotIn("host", []string{"a", "b"})),
+		`SELECT "foo" FROM "bar" WHERE "host" != 'a' AND "host" != 'b'`,
+		false,
+	},
+	{
+		Select("foo").From("bar").Where(And(Eq("region", "west"), In("id", []int{1, 2}))),
+		`SELECT "foo" FROM "bar" WHERE "region" = 'west' AND ("id" = 1 OR "id" = 2)`,
+		false,
+	},
+	{
+		Select("foo").From("bar").Where(In("host", "a")),
+		`SELECT "foo" FROM "bar" WHERE "host" = 'a'`,
+		false,
+	},
+	{
+		Select("foo").From("bar").Where(In("host", "web.1", "a/b").Strategy(InRegex)),
+		`SELECT "foo" FROM "bar" WHERE "host" =~ /^(?:web\.1|a\/b)$/`,
+		false,
+	},
+	{
+		Select("foo").From("bar").Where(Not(In("host", "a", "b").Strategy(InRegex))),
+		`SELECT "foo" FROM "bar" WHERE "host" !~ /^(?:a|b)$/`,
+		false,
+	},
+	{
+		Select("foo").From("bar").Where(In("id", 1, 2).Strategy(InRegex)),
+		``,
+		true, // Only strings can be matched with a regex.
+	},
+	{
+		Select("foo").From("bar").Where(In("host")),
+		``,
+		true, // Empty list.
+	},
+	{
+		Select("*").From(Regex("^cpu/[0-9]+$")).RetentionPolicy("week"),
+		`SELECT * FROM "week"./^cpu\/[0-9]+$/`,
+		false,
+	},
+	{
+		Select("*").From(Regex(`^a\/b/c\\`)),
+		`SELECT * FROM /^a\/b\/c\\/`,
+		false,
+	},
+	{
+		Select("*").From(Regex(`^a\`)),
+		``,
+		true, // Trailing backslash.
+	},
+	{
+		Select("*").From(Regex("(")),
+		``,
+		true, // Invalid regex.
+	},
+	{
+		Select(Mean("value")).From("cpu").Where("host =~ ?", QuoteMeta("web.1")).GroupBy(Regex("^re")),
+		`SELECT MEAN("value") FROM "cpu" WHERE host =~ /^web\.1$/ GROUP BY /^re/`,
+		false,
+	},
+	{
+		Select("*").From("cpu").Where(Match("host", QuoteMeta("a/b", "c|d"))),
+		`SELECT * FROM "cpu" WHERE "host" =~ /^(?:a\/b|c\|d)$/`,
+		false,
+	},
+	{
+		Delete().From(Regex("^tmp_")),
+		`DELETE FROM /^tmp_/`,
+		false,
+	},
+	{
+		ShowFieldKeys().From(Regex("^cpu")),
+		`SHOW FIELD KEYS FROM /^cpu/`,
+		false,
+	},
+	{
+		ShowTagKeys().From("cpu").WithKey(Regex("^ho")),
+		`SHOW TAG KEYS FROM "cpu" WITH KEY =~ /^ho/`,
+		false,
+	},
+	{
+		ShowTagValues().From("cpu").WithKey("host").Where(Eq("region", "west")),
+		`SHOW TAG VALUES FROM "cpu" WITH KEY = "host" WHERE "region" = 'west'`,
+		false,
+	},
+	{
+		ShowTagValues().WithKey([]string{"host", "region"}),
+		`SHOW TAG VALUES WITH KEY IN ("host", "region")`,
+		false,
+	},
+	{
+		ShowTagValues().From("cpu"),
+		``,
+		true, // Missing WITH KEY.
+	},
+	{
+		ShowMeasurements().WithMeasurement(Regex("^cpu")),
+		`SHOW MEASUREMENTS WITH MEASUREMENT =~ /^cpu/`,
+		false,
+	},
+	{
+		ShowMeasurements().WithMeasurement("cpu"),
+		`SHOW MEASUREMENTS WITH MEASUREMENT = "cpu"`,
+		false,
+	},
+	{
+		ShowMeasurements().WithMeasurement([]string{"cpu"}),
+		``,
+		true, // WITH MEASUREMENT does not support lists.
+	},
+	{
+		Select("foo").From("bar").Where("host = ?", `x' OR 1=1 OR host='`),
+		`SELECT "foo" FROM "bar" WHERE host = 'x\' OR 1=1 OR host=\''`,
+		false,
+	},
+	{
+		Select("foo").From("bar").Where(Eq("path", "C:\\tmp\\")).And("note = ?", "a\nb"),
+		`SELECT "foo" FROM "bar" WHERE "path" = 'C:\\tmp\\' AND note = 'a\nb'`,
+		false,
+	},
+	{
+		CreateRetentionPolicy(`a"b`, "db", time.Hour, 1),
+		`CREATE RETENTION POLICY "a\"b" ON "db" DURATION 1h REPLICATION 1`,
+		false,
+	},
+	{
+		CreateUser("admin", `pa'ss`).Admin(),
+		`CREATE USER "admin" WITH PASSWORD 'pa\'ss' WITH ALL PRIVILEGES`,
+		false,
+	},
+	{
+		CreateUser("reader", "secret"),
+		`CREATE USER "reader" WITH PASSWORD 'secret'`,
+		false,
+	},
+	{
+		Select("cpu.load", `a"b`, "select", "température").From("cpu.stats"),
+		`SELECT "cpu.load", "a\"b", "select", "température" FROM "cpu.stats"`,
+		false,
+	},
+	{
+		Select(Mean("cpu.load").As(`a"b`)).From("cpu").Where("cpu.load >", 1),
+		`SELECT MEAN("cpu.load") AS "a\"b" FROM "cpu" WHERE "cpu.load" > 1`,
+		false,
+	},
+	{
+		Select(Ident("value"), Raw(`"host"::tag`)).From(Ident("cpu")).Where(Raw(`"host"::tag = 'a'`)),
+		`SELECT "value", "host"::tag FROM "cpu" WHERE "host"::tag = 'a'`,
+		false,
+	},
+	{
+		Select(Mean(Ident("value"))).From("cpu").Where(Eq(Raw(`"host"::tag`), "a")),
+		`SELECT MEAN("value") FROM "cpu" WHERE "host"::tag = 'a'`,
+		false,
+	},
+	{
+		Select("*").From("cpu").Where(Raw("a = 1"), 1),
+		``,
+		true, // Values are not allowed along with a Builder.
+	},
+	{
+		Select("*").From("cpu").Where("usage > ?", 0.5).And(Eq("idle", true)).And(Lt("load", float32(1.25))),
+		`SELECT * FROM "cpu" WHERE usage > 0.5 AND "idle" = true AND "load" < 1.25`,
+		false,
+	},
+	{
+		Select("*").From("cpu").Where(Gt("usage", 1e21)).And(Eq("count", int16(-3))),
+		`SELECT * FROM "cpu" WHERE "usage" > 1000000000000000000000 AND "count" = -3`,
+		false,
+	},
+	{
+		Select("*").From("cpu").Where(Eq("a", json.Number("18446744073709551615"))).And(Eq("b", json.Number("1.5e3"))),
+		`SELECT * FROM "cpu" WHERE "a" = 18446744073709551615 AND "b" = 1500`,
+		false,
+	},
+	{
+		Select("*").From("cpu").Where(Eq("a", json.Number("1; DROP"))),
+		``,
+		true, // Invalid number.
+	},
+	{
+		Select("*").From("cpu").Where(Eq("usage", math.NaN())),
+		``,
+		true, // NaN has no literal.
+	},
+	{
+		Select("*").From("cpu").Where(Eq("state", testState(2))).And(Eq("id", testID(7))),
+		`SELECT * FROM "cpu" WHERE "state" = 2 AND "id" = 'id-7'`,
+		false,
+	},
+	{
+		Select("*").From("cpu").Where(Eq("host", testHost("a'b"))).And(Eq("ptr", &[]int{5}[0])),
+		`SELECT * FROM "cpu" WHERE "host" = 'a\'b' AND "ptr" = 5`,
+		false,
+	},
+	{
+		Select("*").From("cpu").Where(Eq("id", testID(-1))),
+		``,
+		true, // Valuer error.
+	},
+	{
+		Select("*").From("cpu").Where(Eq("host", nil)),
+		``,
+		true, // No null literal.
+	},
+	{
+		Select("*").From("cpu").Where(Eq("host", struct{}{})),
+		``,
+		true, // Unsupported type.
+	},
+	{
+		Select("*").From("cpu").Where(Gte("time", time.Date(2015, 8, 18, 3, 0, 0, 123456789, time.FixedZone("EST", -5*3600)))),
+		`SELECT * FROM "cpu" WHERE "time" >= '2015-08-18T08:00:00.123456789Z'`,
+		false,
+	},
+	{
+		Select("*").From("cpu").Where(Gte("time", time.Unix(1439856000, 123456789))).Epoch(PrecisionNanosecond),
+		`SELECT * FROM "cpu" WHERE "time" >= 1439856000123456789ns`,
+		false,
+	},
+	{
+		Select("*").From("cpu").Where("time >= ?", time.Unix(1439856000, 123456789)).Epoch(PrecisionMicrosecond),
+		`SELECT * FROM "cpu" WHERE time >= 1439856000123456u`,
+		false,
+	},
+	{
+		Delete().From("cpu").Where(Lt("time", time.Unix(1439856000, 123456789))).Epoch(PrecisionMillisecond),
+		`DELETE FROM "cpu" WHERE "time" < 1439856000123ms`,
+		false,
+	},
+	{
+		ShowTagKeys().Where(Gt("time", time.Unix(-1, 500000000))).Epoch(PrecisionSecond),
+		`SHOW TAG KEYS WHERE "time" > -1s`,
+		false,
+	},
+	{
+		Select("*").From("cpu").Where(Gt("time", time.Unix(0, 0))).Epoch("h"),
+		``,
+		true, // Unsupported precision.
+	},
+	{
+		Select("*").From("cpu").Where(Since(time.Hour)),
+		`SELECT * FROM "cpu" WHERE "time" >= now() - 1h`,
+		false,
+	},
+	{
+		Select("*").From("cpu").Where(Gt("time", Now().Sub(time.Hour).Add(time.Minute*90))).And(Lt("time", Now())),
+		`SELECT * FROM "cpu" WHERE "time" > now() + 30m AND "time" < now()`,
+		false,
+	},
+	{
+		Select("*").From("cpu").Where(Eq("host", "a")).Or(TimeRange(Now().Sub(time.Hour*2), Now().Sub(time.Hour))),
+		`SELECT * FROM "cpu" WHERE "host" = 'a' OR ("time" >= now() - 2h AND "time" < now() - 1h)`,
+		false,
+	},
+	{
+		Delete().From("cpu").Where(TimeRange(time.Unix(0, 0), time.Unix(60, 0))).Epoch(PrecisionSecond),
+		`DELETE FROM "cpu" WHERE "time" >= 0s AND "time" < 60s`,
+		false,
+	},
+	{
+		ShowTagValues().WithKey("host").Where(Not(Since(time.Minute))),
+		`SHOW TAG VALUES WITH KEY = "host" WHERE "time" < now() - 1m`,
+		false,
+	},
+	{
+		CreateRetentionPolicy("forever", "db", Infinite, 1).ShardDuration(time.Hour * 24 * 7),
+		`CREATE RETENTION POLICY "forever" ON "db" DURATION INF REPLICATION 1 SHARD DURATION 1w`,
+		false,
+	},
+	{
+		Select(Mean("value")).From("cpu").GroupBy(Time(time.Minute * 90)),
+		`SELECT MEAN("value") FROM "cpu" GROUP BY time(1h30m)`,
+		false,
+	},
+	{
+		Select("*").From("cpu").Where(Gt("time", Now().Sub(time.Millisecond*1500))),
+		`SELECT * FROM "cpu" WHERE "time" > now() - 1s500ms`,
+		false,
+	},
+	{
+		Select("*").From("cpu").Where(`host = ? AND note = 'why?' AND "odd?" = ? AND path =~ /a?b/`, "a", 1),
+		`SELECT * FROM "cpu" WHERE host = 'a' AND note = 'why?' AND "odd?" = 1 AND path =~ /a?b/`,
+		false,
+	},
+	{
+		Select("*").From("cpu").Where(`usage > ? AND note = '100%' AND ratio = 10 / 2`, 50),
+		`SELECT * FROM "cpu" WHERE usage > 50 AND note = '100%' AND ratio = 10 / 2`,
+		false,
+	},
+	{
+		Select("*").From("cpu").Where(`host = ? AND note = 'it\'s?'`, "%s"),
+		`SELECT * FROM "cpu" WHERE host = '%s' AND note = 'it\'s?'`,
+		false,
+	},
+	{
+		Select("*").From("cpu").Where(`host = :host AND "host"::tag != @host AND region = :region`, map[string]interface{}{"host": "a", "region": "west"}),
+		`SELECT * FROM "cpu" WHERE host = 'a' AND "host"::tag != 'a' AND region = 'west'`,
+		false,
+	},
+	{
+		Select("*").From("cpu").Where(`host = :host AND usage > :Usage`, struct {
+			Host  string `influx:"host"`
+			Usage float64
+		}{"a", 0.5}),
+		`SELECT * FROM "cpu" WHERE host = 'a' AND usage > 0.5`,
+		false,
+	},
+	{
+		Select("*").From("cpu").Where(`host = :host`, map[string]string{"region": "west"}),
+		``,
+		true, // Missing named value.
+	},
+	{
+		Select("*").From("cpu").Where(`host = :host AND region = ?`, "a"),
+		``,
+		true, // Mixed positional and named placeholders.
+	},
+	{
+		Select("*").From("cpu").Where(`host = 'a`, "a"),
+		``,
+		true, // Unterminated literal.
+	},
 	{
 		CreateDatabase("name"),
 		`CREATE DATABASE "name"`,
@@ -247,3 +802,408 @@ func TestSelect(t *testing.T) {
 		assert.Equal(t, sample.s, s)
 	}
 }
+
+func TestLookupFunc(t *testing.T) {
+	spec, ok := LookupFunc("holt_winters")
+	assert.True(t, ok)
+	assert.Equal(t, "HOLT_WINTERS", spec.Name)
+	assert.Equal(t, Predictor, spec.Kind)
+	assert.Equal(t, GroupByTimeRequired, spec.GroupByTime)
+
+	min, max := spec.Arity()
+	assert.Equal(t, 3, min)
+	assert.Equal(t, 3, max)
+
+	spec, ok = LookupFunc("TOP")
+	assert.True(t, ok)
+	min, max = spec.Arity()
+	assert.Equal(t, 2, min)
+	assert.Equal(t, -1, max)
+
+	// The arguments are a copy, the catalog is not changed through them.
+	spec.Args[0].Name = "changed"
+	spec, _ = LookupFunc("TOP")
+	assert.Equal(t, "field", spec.Args[0].Name)
+
+	_, ok = LookupFunc("MY_FUNCTION")
+	assert.False(t, ok)
+
+	functions := Functions()
+	assert.Equal(t, len(builtinFunctions), len(functions))
+	for i := 1; i < len(functions); i++ {
+		assert.True(t, functions[i-1].Name < functions[i].Name)
+	}
+}
+
+func TestValidateSelect(t *testing.T) {
+	_, err := Select("host", Derivative(Mean(Max("value")))).From("cpu").Build()
+
+	errs, ok := err.(ValidationErrors)
+	assert.True(t, ok)
+	assert.Len(t, errs, 3)
+
+	kinds := []ValidationErrorKind{}
+	for _, e := range errs {
+		kinds = append(kinds, e.Kind)
+	}
+	assert.Equal(t, []ValidationErrorKind{
+		MissingGroupByTime, NestedAggregate, MixedAggregates,
+	}, kinds)
+	assert.Equal(t, "DERIVATIVE", errs[0].Func)
+	assert.Equal(t, 1, errs[0].Field)
+	assert.Equal(t, "MEAN", errs[1].Func)
+	assert.Equal(t, 0, errs[2].Field)
+	assert.EqualError(t, err, "Field 2: DERIVATIVE applied to an aggregation requires GROUP BY time(). "+
+		"Field 2: MEAN cannot aggregate the result of MAX. "+
+		"Field 1: raw fields cannot be mixed with aggregations.")
+
+	s, err := Select(Derivative(Mean(Field("value")))).From("cpu").GroupBy(Raw("time(1m)")).Build()
+	assert.NoError(t, err)
+	assert.Equal(t, `SELECT DERIVATIVE(MEAN("value")) FROM "cpu" GROUP BY time(1m)`, s)
+
+	_, err = Select(Derivative(Mean(Field("value")))).From("cpu").GroupBy(Raw("host")).Build()
+	assert.IsType(t, ValidationErrors{}, err)
+}
+
+func TestRegisterFunc(t *testing.T) {
+	t.Cleanup(func() { unregisterFunc("test_rank") })
+
+	err := RegisterFunc(FuncSpec{
+		Name: "test_rank",
+		Kind: Selector,
+		Args: []ArgSpec{
+			{Name: "field", Type: ArgField},
+			{Name: "N", Type: ArgNumber},
+			{Name: "unit", Type: ArgDuration, Optional: true},
+		},
+	})
+	assert.NoError(t, err)
+
+	spec, ok := LookupFunc("TEST_RANK")
+	assert.True(t, ok)
+	assert.Equal(t, Selector, spec.Kind)
+
+	s, err := Select(Func("TEST_RANK", Field("value"), 0.5, time.Hour)).From("cpu").Build()
+	assert.NoError(t, err)
+	assert.Equal(t, `SELECT TEST_RANK("value", 0.5, 1h) FROM "cpu"`, s)
+
+	_, err = Select(Func("TEST_RANK", Field("value"))).From("cpu").Build()
+	assert.Error(t, err)
+
+	_, err = Select(Func("TEST_RANK", Field("value"), "x")).From("cpu").Build()
+	assert.Error(t, err)
+
+	_, err = Select(Mean(Func("TEST_RANK", Field("value"), 1))).From("cpu").Build()
+	assert.IsType(t, ValidationErrors{}, err)
+
+	assert.Error(t, RegisterFunc(FuncSpec{Name: "test_rank", Kind: Selector}))
+	assert.Error(t, RegisterFunc(FuncSpec{Name: "MEAN", Kind: Aggregate}))
+	assert.Error(t, RegisterFunc(FuncSpec{Name: "bad name", Kind: Math}))
+	assert.Error(t, RegisterFunc(FuncSpec{
+		Name: "test_invalid",
+		Kind: Math,
+		Args: []ArgSpec{
+			{Name: "x", Type: ArgField, Optional: true},
+			{Name: "y", Type: ArgField},
+		},
+	}))
+}
+
+func TestConditionErrors(t *testing.T) {
+	_, err := Select("foo").From("bar").Where(Eq("a", 1), 2).Build()
+	assert.EqualError(t, err, "Unexpected values given along with a Condition.")
+
+	_, err = Select("foo").From("bar").Where(And()).Build()
+	assert.EqualError(t, err, "Empty AND condition.")
+
+	_, err = Select("foo").From("bar").Where(In("host")).Build()
+	assert.EqualError(t, err, "Empty list of values.")
+
+	_, err = Select("foo").From("bar").Where(In("id", 1, 2).Strategy(InRegex)).Build()
+	assert.EqualError(t, err, "Only strings can be matched with a regex.")
+}
+
+func TestFill(t *testing.T) {
+	for v, fill := range map[interface{}]string{
+		"previous":         "previous",
+		"LINEAR":           "linear",
+		"-1.5":             "-1.5",
+		uint8(7):           "7",
+		0.25:               "0.25",
+		json.Number("1e3"): "1000",
+		testState(3):       "3",
+	} {
+		s, err := Select(Mean("value")).From("cpu").GroupBy(Time(time.Minute)).Fill(v).Build()
+		assert.NoError(t, err)
+		assert.Equal(t, `SELECT MEAN("value") FROM "cpu" GROUP BY time(1m) fill(`+fill+`)`, s)
+	}
+
+	_, err := Select(Mean("value")).From("cpu").GroupBy(Time(time.Minute)).Fill("0) ; DROP DATABASE x").Build()
+	assert.EqualError(t, err, `Invalid fill option "0) ; DROP DATABASE x".`)
+
+	_, err = Select(Mean("value")).From("cpu").GroupBy(Time(time.Minute)).Fill(true).Build()
+	assert.EqualError(t, err, "Invalid fill option of type bool.")
+
+	_, err = Select(Mean("value")).From("cpu").GroupBy(Time(time.Minute)).Fill(math.Inf(1)).Build()
+	assert.Error(t, err)
+}
+
+func TestInAuto(t *testing.T) {
+	hosts := []string{}
+	for i := 0; i <= inRegexThreshold; i++ {
+		hosts = append(hosts, fmt.Sprintf("host-%d", i))
+	}
+
+	s, err := In("host", hosts).Build()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(s, `"host" =~ /^(?:host-0|host-1|`))
+
+	s, err = In("host", hosts[:inRegexThreshold]).Build()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(s, `"host" = 'host-0' OR "host" = 'host-1' OR `))
+}
+
+// unquote scans an InfluxQL string literal or quoted identifier at the
+// beginning of s the way the InfluxQL scanner does, it returns the decoded
+// value and the rest of s.
+func unquote(s string, quote byte) (string, string, bool) {
+	if len(s) == 0 || s[0] != quote {
+		return "", "", false
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case quote:
+			return b.String(), s[i+1:], true
+		case '\n':
+			return "", "", false
+		case '\\':
+			i++
+			if i == len(s) {
+				return "", "", false
+			}
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case '\\', '\'', '"':
+				b.WriteByte(s[i])
+			default:
+				return "", "", false
+			}
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", "", false
+}
+
+func FuzzValue(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		"Toronto",
+		`x' OR 1=1 OR host='`,
+		`\'; DROP DATABASE foo; --`,
+		"a\nb\\",
+		"?%s%d",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		queries := []struct {
+			b      Builder
+			prefix string
+			suffix string
+		}{
+			{
+				Select("foo").From("bar").Where("host = ?", input),
+				`SELECT "foo" FROM "bar" WHERE host = `,
+				``,
+			},
+			{
+				Select("foo").From("bar").Where(Eq("host", input)),
+				`SELECT "foo" FROM "bar" WHERE "host" = `,
+				``,
+			},
+			{
+				Func("MY_FUNCTION", input),
+				`MY_FUNCTION(`,
+				`)`,
+			},
+			{
+				CreateUser("user", input),
+				`CREATE USER "user" WITH PASSWORD `,
+				``,
+			},
+		}
+
+		for _, query := range queries {
+			s, err := query.b.Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(s, query.prefix) {
+				t.Fatalf("query structure changed: %s", s)
+			}
+			decoded, rest, ok := unquote(s[len(query.prefix):], '\'')
+			if !ok || rest != query.suffix || decoded != input {
+				t.Fatalf("query structure changed: %s", s)
+			}
+		}
+	})
+}
+
+func FuzzIdent(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		"cpu.load",
+		`a"b`,
+		"select",
+		"température",
+		`" FROM "x"; DROP DATABASE "y`,
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		s, err := Select(input).From("bar").Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if input == "*" {
+			return
+		}
+
+		prefix := `SELECT `
+		if !strings.HasPrefix(s, prefix) {
+			t.Fatalf("query structure changed: %s", s)
+		}
+		decoded, rest, ok := unquote(s[len(prefix):], '"')
+		if !ok || rest != ` FROM "bar"` || decoded != input {
+			t.Fatalf("query structure changed: %s", s)
+		}
+	})
+}
+
+func TestTimeFormat(t *testing.T) {
+	for d, s := range map[time.Duration]string{
+		0:                                "0s",
+		time.Nanosecond:                  "1ns",
+		1500 * time.Microsecond:          "1ms500u",
+		1500 * time.Millisecond:          "1s500ms",
+		-1500 * time.Millisecond:         "-1s500ms",
+		time.Hour * 24 * 7:               "1w",
+		time.Hour * 24 * 8:               "1w1d",
+		time.Hour * 36:                   "1d12h",
+		time.Minute*90 + time.Nanosecond: "1h30m1ns",
+		time.Duration(math.MinInt64):     "-15250w1d23h47m16s854ms775u808ns",
+	} {
+		assert.Equal(t, s, timeFormat(d))
+	}
+}
+
+func TestEpoch(t *testing.T) {
+	maxTime := time.Unix(0, math.MaxInt64)
+	minTime := time.Unix(0, math.MinInt64)
+
+	for _, sample := range []struct {
+		t         time.Time
+		precision Precision
+		n         int64
+	}{
+		{maxTime, PrecisionNanosecond, math.MaxInt64},
+		{minTime, PrecisionNanosecond, math.MinInt64},
+		{time.Unix(-1, 500), PrecisionMicrosecond, -1000000},
+		{time.Unix(-1, 1500), PrecisionMicrosecond, -999999},
+		{time.Date(2300, 1, 1, 0, 0, 0, 0, time.UTC), PrecisionMillisecond, 10413792000000},
+		{time.Date(1000, 1, 1, 0, 0, 0, 0, time.UTC), PrecisionSecond, -30610224000},
+	} {
+		n, err := epoch(sample.t, sample.precision)
+		assert.NoError(t, err)
+		assert.Equal(t, sample.n, n)
+	}
+
+	_, err := epoch(maxTime.Add(time.Nanosecond), PrecisionNanosecond)
+	assert.EqualError(t, err, "Time 2262-04-11T23:47:16.854775808Z is out of the range of epochs in ns.")
+
+	_, err = epoch(minTime.Add(-time.Nanosecond), PrecisionNanosecond)
+	assert.Error(t, err)
+
+	_, err = Select("*").From("cpu").Where(Gte("time", time.Date(2300, 1, 1, 0, 0, 0, 0, time.UTC))).
+		Epoch(PrecisionNanosecond).Build()
+	assert.Error(t, err)
+}
+
+func TestBuildParams(t *testing.T) {
+	samples := []struct {
+		b      ParamsBuilder
+		s      string
+		params map[string]interface{}
+	}{
+		{
+			Select("*").From("cpu").Where("host = ? AND usage > ?", `x' OR 1=1 OR host='`, 0.5).
+				And(In("region", "west", "east")).
+				And(Gte("time", time.Date(2015, 8, 18, 0, 0, 0, 1, time.UTC))).
+				And(Lt("time", Now().Sub(time.Hour))),
+			`SELECT * FROM "cpu" WHERE host = $p1 AND usage > $p2 AND ("region" = $p3 OR "region" = $p4) AND "time" >= $p5 AND "time" < now() - 1h`,
+			map[string]interface{}{
+				"p1": `x' OR 1=1 OR host='`,
+				"p2": 0.5,
+				"p3": "west",
+				"p4": "east",
+				"p5": "2015-08-18T00:00:00.000000001Z",
+			},
+		},
+		{
+			Select(Mean("value")).From("cpu").Where(Eq("state", testState(2))).And(Eq("id", testID(7))).GroupBy(Time(time.Minute)),
+			`SELECT MEAN("value") FROM "cpu" WHERE "state" = $p1 AND "id" = $p2 GROUP BY time(1m)`,
+			map[string]interface{}{
+				"p1": int64(2),
+				"p2": "id-7",
+			},
+		},
+		{
+			Delete().From("cpu").Where(Eq("host", "a")).And(Match("region", "^w")),
+			`DELETE FROM "cpu" WHERE "host" = $p1 AND "region" =~ /^w/`,
+			map[string]interface{}{
+				"p1": "a",
+			},
+		},
+		{
+			ShowTagValues().WithKey("host").Where(Eq("region", json.Number("1"))),
+			`SHOW TAG VALUES WITH KEY = "host" WHERE "region" = $p1`,
+			map[string]interface{}{
+				"p1": json.Number("1"),
+			},
+		},
+		{
+			Select("*").From("cpu").Where(Gte("time", time.Unix(1439856000, 123456789))).Epoch(PrecisionMillisecond),
+			`SELECT * FROM "cpu" WHERE "time" >= $p1`,
+			map[string]interface{}{
+				"p1": int64(1439856000123000000),
+			},
+		},
+		{
+			ShowMeasurements(),
+			`SHOW MEASUREMENTS`,
+			map[string]interface{}{},
+		},
+	}
+
+	for _, sample := range samples {
+		s, params, err := sample.b.BuildParams()
+		assert.NoError(t, err)
+		assert.Equal(t, sample.s, s)
+		assert.Equal(t, sample.params, params)
+	}
+
+	_, _, err := Select("*").From("cpu").Where(Eq("usage", math.NaN())).BuildParams()
+	assert.Error(t, err)
+
+	// The time fits in milliseconds but not in the nanoseconds it is bound
+	// as.
+	_, _, err = Select("*").From("cpu").Where(Gte("time", time.Date(2300, 1, 1, 0, 0, 0, 0, time.UTC))).
+		Epoch(PrecisionMillisecond).BuildParams()
+	assert.EqualError(t, err, "Time 2300-01-01T00:00:00Z is out of the range of epochs in ns.")
+}
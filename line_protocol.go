@@ -0,0 +1,303 @@
+package influxql
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseError describes invalid line protocol input.
+type ParseError struct {
+	// Line and Column are 1-based, the column counts bytes.
+	Line   int
+	Column int
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Reason)
+}
+
+// reFloat matches the float values of the line protocol, it leaves out the
+// hexadecimal floats and the digit separators strconv accepts.
+var reFloat = regexp.MustCompile(`^-?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][-+]?[0-9]+)?$`)
+
+// ParseLineProtocol parses points written in the line protocol. Timestamps
+// are read with the given precision, RFC3339 means nanoseconds. Blank lines
+// and comments starting with # are skipped, string field values may span
+// several lines.
+func ParseLineProtocol(data []byte, precision Precision) ([]*Point, error) {
+	if precision == RFC3339 {
+		precision = PrecisionNanosecond
+	}
+	unit, ok := precisionUnits[precision]
+	if !ok {
+		return nil, fmt.Errorf("Unsupported precision %q.", string(precision))
+	}
+
+	p := &lineParser{data: data, line: 1, unit: int64(unit)}
+
+	var points []*Point
+	for {
+		p.skipSpaces()
+		if p.eof() {
+			return points, nil
+		}
+
+		switch p.peek() {
+		case '\n':
+			p.newline()
+			continue
+		case '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+			continue
+		}
+
+		point, err := p.parsePoint()
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+}
+
+type lineParser struct {
+	data      []byte
+	pos       int
+	line      int
+	lineStart int
+	unit      int64
+}
+
+func (p *lineParser) eof() bool {
+	return p.pos >= len(p.data)
+}
+
+func (p *lineParser) peek() byte {
+	return p.data[p.pos]
+}
+
+// eol tells whether the parser is at the end of a line.
+func (p *lineParser) eol() bool {
+	return p.eof() || p.peek() == '\n'
+}
+
+func (p *lineParser) newline() {
+	p.pos++
+	p.line++
+	p.lineStart = p.pos
+}
+
+func (p *lineParser) skipSpaces() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *lineParser) errorAt(pos int, format string, args ...interface{}) error {
+	return &ParseError{
+		Line:   p.line,
+		Column: pos - p.lineStart + 1,
+		Reason: fmt.Sprintf(format, args...),
+	}
+}
+
+// scan reads up to an unescaped byte of stop or the end of the line. A
+// backslash followed by a byte of escapes is replaced by that byte, other
+// backslashes are kept.
+func (p *lineParser) scan(stop string, escapes string) string {
+	var b strings.Builder
+	for !p.eol() {
+		c := p.peek()
+		if c == '\\' && p.pos+1 < len(p.data) &&
+			strings.IndexByte(escapes, p.data[p.pos+1]) >= 0 {
+			b.WriteByte(p.data[p.pos+1])
+			p.pos += 2
+			continue
+		}
+		if c == '\r' || strings.IndexByte(stop, c) >= 0 {
+			break
+		}
+		b.WriteByte(c)
+		p.pos++
+	}
+	return b.String()
+}
+
+func (p *lineParser) parsePoint() (*Point, error) {
+	start := p.pos
+	measurement := p.scan(", \t", ", ")
+	if measurement == "" {
+		return nil, p.errorAt(start, "missing measurement")
+	}
+	point := NewPoint(measurement)
+
+	for !p.eol() && p.peek() == ',' {
+		p.pos++
+
+		start := p.pos
+		key := p.scan("=, \t", ",= ")
+		if key == "" {
+			return nil, p.errorAt(start, "missing tag key")
+		}
+		if p.eol() || p.peek() != '=' {
+			return nil, p.errorAt(p.pos, "missing value of tag %q", key)
+		}
+		p.pos++
+
+		start = p.pos
+		value := p.scan(", \t", ",= ")
+		if value == "" {
+			return nil, p.errorAt(start, "missing value of tag %q", key)
+		}
+		point.Tag(key, value)
+	}
+
+	if p.eol() || (p.peek() != ' ' && p.peek() != '\t') {
+		return nil, p.errorAt(p.pos, "missing fields")
+	}
+	p.skipSpaces()
+
+	for {
+		start := p.pos
+		key := p.scan("=, \t", ",= ")
+		if key == "" {
+			return nil, p.errorAt(start, "missing field key")
+		}
+		if p.eol() || p.peek() != '=' {
+			return nil, p.errorAt(p.pos, "missing value of field %q", key)
+		}
+		p.pos++
+
+		value, err := p.parseFieldValue(key)
+		if err != nil {
+			return nil, err
+		}
+		point.Field(key, value)
+
+		if p.eol() || p.peek() != ',' {
+			break
+		}
+		p.pos++
+	}
+
+	if !p.eol() && p.peek() != ' ' && p.peek() != '\t' && p.peek() != '\r' {
+		return nil, p.errorAt(p.pos, "unexpected %q after fields", p.peek())
+	}
+	p.skipSpaces()
+
+	if !p.eol() {
+		start := p.pos
+		raw := p.scan(" \t", "")
+		t, err := p.parseTimestamp(raw)
+		if err != nil {
+			return nil, p.errorAt(start, "%v", err)
+		}
+		point.Time(t)
+
+		p.skipSpaces()
+		if !p.eol() {
+			return nil, p.errorAt(p.pos, "unexpected text after timestamp")
+		}
+	}
+
+	return point, nil
+}
+
+func (p *lineParser) parseFieldValue(key string) (interface{}, error) {
+	start := p.pos
+	if !p.eol() && p.peek() == '"' {
+		return p.parseString(key)
+	}
+
+	raw := p.scan(", \t", "")
+	if raw == "" {
+		return nil, p.errorAt(start, "missing value of field %q", key)
+	}
+
+	switch raw {
+	case "t", "T", "true", "True", "TRUE":
+		return true, nil
+	case "f", "F", "false", "False", "FALSE":
+		return false, nil
+	}
+
+	if !looksNumeric(raw) {
+		return nil, p.errorAt(start, "invalid value %q of field %q", raw, key)
+	}
+
+	switch raw[len(raw)-1] {
+	case 'i':
+		n, err := strconv.ParseInt(raw[:len(raw)-1], 10, 64)
+		if err != nil {
+			return nil, p.errorAt(start, "invalid integer %q of field %q", raw, key)
+		}
+		return n, nil
+	case 'u':
+		n, err := strconv.ParseUint(raw[:len(raw)-1], 10, 64)
+		if err != nil {
+			return nil, p.errorAt(start, "invalid unsigned integer %q of field %q", raw, key)
+		}
+		return n, nil
+	}
+
+	if !reFloat.MatchString(raw) {
+		return nil, p.errorAt(start, "invalid float %q of field %q", raw, key)
+	}
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsInf(f, 0) {
+		return nil, p.errorAt(start, "invalid float %q of field %q", raw, key)
+	}
+	return f, nil
+}
+
+// parseString reads a double-quoted string value, \" and \\ are unescaped
+// and line breaks are kept.
+func (p *lineParser) parseString(key string) (string, error) {
+	line, lineStart, start := p.line, p.lineStart, p.pos
+	p.pos++
+
+	var b strings.Builder
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case c == '\\' && p.pos+1 < len(p.data) &&
+			(p.data[p.pos+1] == '"' || p.data[p.pos+1] == '\\'):
+			b.WriteByte(p.data[p.pos+1])
+			p.pos += 2
+		case c == '"':
+			p.pos++
+			return b.String(), nil
+		case c == '\n':
+			b.WriteByte(c)
+			p.newline()
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	p.line, p.lineStart = line, lineStart
+	return "", p.errorAt(start, "unterminated string value of field %q", key)
+}
+
+func (p *lineParser) parseTimestamp(raw string) (time.Time, error) {
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", raw)
+	}
+	if n > math.MaxInt64/p.unit || n < math.MinInt64/p.unit {
+		return time.Time{}, fmt.Errorf("timestamp %q out of range", raw)
+	}
+	return time.Unix(0, n*p.unit).UTC(), nil
+}
@@ -0,0 +1,484 @@
+package influxql
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// Extension types of times in MessagePack: InfluxDB encodes them with the
+// extension of github.com/tinylib/msgp, -1 is the standard timestamp.
+const (
+	msgpackTimeExt      = 5
+	msgpackTimestampExt = -1
+)
+
+// msgpackMaxDepth limits the nesting of decoded values, responses are at most
+// five levels deep.
+const msgpackMaxDepth = 32
+
+var errMsgpackDepth = errors.New("value nested too deeply")
+
+// DecodeMsgpackResponse decodes a /query response in the application/x-msgpack
+// format into the same model as DecodeResponse. The epoch is the precision
+// the query was sent with. Unlike JSON, MessagePack keeps the type of numbers,
+// so a float field holding 1 is decoded as a float64.
+func DecodeMsgpackResponse(r io.Reader, epoch Precision) (*Response, error) {
+	decoder := &msgpackDecoder{r: bufio.NewReader(r)}
+
+	var resp *Response
+	for {
+		if _, err := decoder.r.Peek(1); err == io.EOF && resp != nil {
+			return resp, nil
+		}
+
+		v, err := decoder.decode(0)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to decode MessagePack response: %v.", err)
+		}
+
+		chunk, err := msgpackToResponse(v, epoch)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to decode MessagePack response: %v.", err)
+		}
+
+		if resp == nil {
+			resp = chunk
+		} else {
+			resp.merge(chunk)
+		}
+	}
+}
+
+// msgpackDecoder decodes MessagePack values into nil, bool, int64, uint64,
+// float64, string, []byte, time.Time, []interface{} and
+// map[string]interface{}.
+type msgpackDecoder struct {
+	r *bufio.Reader
+}
+
+func (d *msgpackDecoder) read(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (d *msgpackDecoder) uint(size int) (uint64, error) {
+	buf, err := d.read(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(buf[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(buf)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(buf)), nil
+	}
+	return binary.BigEndian.Uint64(buf), nil
+}
+
+func (d *msgpackDecoder) int(size int) (int64, error) {
+	u, err := d.uint(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return int64(int8(u)), nil
+	case 2:
+		return int64(int16(u)), nil
+	case 4:
+		return int64(int32(u)), nil
+	}
+	return int64(u), nil
+}
+
+func (d *msgpackDecoder) decode(depth int) (interface{}, error) {
+	if depth > msgpackMaxDepth {
+		return nil, errMsgpackDepth
+	}
+
+	c, err := d.r.ReadByte()
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return d.decodeMap(int(c&0x0f), depth)
+	case c&0xf0 == 0x90:
+		return d.decodeArray(int(c&0x0f), depth)
+	case c&0xe0 == 0xa0:
+		return d.decodeString(int(c & 0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return d.readLen(n)
+
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.uint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeExt(n)
+
+	case 0xca:
+		u, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := d.uint(8)
+		return math.Float64frombits(u), err
+
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := d.uint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if u > math.MaxInt64 {
+			return u, nil
+		}
+		return int64(u), nil
+
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		return d.int(1 << (c - 0xd0))
+
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.decodeExt(1 << (c - 0xd4))
+
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeString(int(n))
+
+	case 0xdc, 0xdd:
+		n, err := d.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(int(n), depth)
+
+	case 0xde, 0xdf:
+		n, err := d.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(int(n), depth)
+	}
+
+	return nil, fmt.Errorf("unsupported type 0x%02x", c)
+}
+
+// readLen reads n bytes, the buffer grows as data arrives so a corrupted
+// length does not allocate more than the payload.
+func (d *msgpackDecoder) readLen(n uint64) ([]byte, error) {
+	if n > math.MaxInt32 {
+		return nil, fmt.Errorf("length %d too large", n)
+	}
+	buf, err := io.ReadAll(io.LimitReader(d.r, int64(n)))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(buf)) != n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return buf, nil
+}
+
+func (d *msgpackDecoder) decodeString(n int) (string, error) {
+	buf, err := d.readLen(uint64(n))
+	return string(buf), err
+}
+
+func (d *msgpackDecoder) decodeArray(n int, depth int) ([]interface{}, error) {
+	values := make([]interface{}, 0, minInt(n, 1024))
+	for i := 0; i < n; i++ {
+		v, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func (d *msgpackDecoder) decodeMap(n int, depth int) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, minInt(n, 1024))
+	for i := 0; i < n; i++ {
+		k, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected map key of type %T", k)
+		}
+		v, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = v
+	}
+	return values, nil
+}
+
+func (d *msgpackDecoder) decodeExt(n uint64) (interface{}, error) {
+	typ, err := d.int(1)
+	if err != nil {
+		return nil, err
+	}
+	data, err := d.readLen(n)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case typ == msgpackTimeExt && len(data) == 12:
+		sec := int64(binary.BigEndian.Uint64(data[:8]))
+		nsec := int64(binary.BigEndian.Uint32(data[8:]))
+		return time.Unix(sec, nsec).UTC(), nil
+
+	case typ == msgpackTimestampExt && len(data) == 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(data)), 0).UTC(), nil
+	case typ == msgpackTimestampExt && len(data) == 8:
+		u := binary.BigEndian.Uint64(data)
+		return time.Unix(int64(u&(1<<34-1)), int64(u>>34)).UTC(), nil
+	case typ == msgpackTimestampExt && len(data) == 12:
+		sec := int64(binary.BigEndian.Uint64(data[4:]))
+		nsec := int64(binary.BigEndian.Uint32(data[:4]))
+		return time.Unix(sec, nsec).UTC(), nil
+	}
+
+	return nil, fmt.Errorf("unsupported extension type %d", typ)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// msgpackToResponse converts a decoded response object to Response.
+func msgpackToResponse(v interface{}, epoch Precision) (*Response, error) {
+	object, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected response of type %T", v)
+	}
+
+	resp := &Response{}
+	if err := msgpackField(object, "error", &resp.Err); err != nil {
+		return nil, err
+	}
+
+	results, err := msgpackArray(object, "results")
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range results {
+		// InfluxDB omits the statement id of failed statements, they follow
+		// the previous statement.
+		id := 0
+		if n := len(resp.Results); n > 0 {
+			id = resp.Results[n-1].StatementID + 1
+		}
+		result, err := msgpackToResult(item, id, epoch)
+		if err != nil {
+			return nil, err
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	return resp, nil
+}
+
+func msgpackToResult(v interface{}, id int, epoch Precision) (*Result, error) {
+	object, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected result of type %T", v)
+	}
+
+	result := &Result{}
+	statementID := int64(id)
+	if err := msgpackField(object, "statement_id", &statementID); err != nil {
+		return nil, err
+	}
+	result.StatementID = int(statementID)
+	if err := msgpackField(object, "error", &result.Err); err != nil {
+		return nil, err
+	}
+	if err := msgpackField(object, "partial", &result.Partial); err != nil {
+		return nil, err
+	}
+
+	messages, err := msgpackArray(object, "messages")
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range messages {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected message of type %T", item)
+		}
+		message := &Message{}
+		if err := msgpackField(fields, "level", &message.Level); err != nil {
+			return nil, err
+		}
+		if err := msgpackField(fields, "text", &message.Text); err != nil {
+			return nil, err
+		}
+		result.Messages = append(result.Messages, message)
+	}
+
+	series, err := msgpackArray(object, "series")
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range series {
+		s, err := msgpackToSeries(item, epoch)
+		if err != nil {
+			return nil, err
+		}
+		result.Series = append(result.Series, s)
+	}
+
+	return result, nil
+}
+
+func msgpackToSeries(v interface{}, epoch Precision) (*Series, error) {
+	object, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected series of type %T", v)
+	}
+
+	s := &Series{}
+	if err := msgpackField(object, "name", &s.Name); err != nil {
+		return nil, err
+	}
+	if err := msgpackField(object, "partial", &s.Partial); err != nil {
+		return nil, err
+	}
+
+	if tags, ok := object["tags"].(map[string]interface{}); ok {
+		s.Tags = make(map[string]string, len(tags))
+		for k, tag := range tags {
+			value, ok := tag.(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected value of tag %q of type %T", k, tag)
+			}
+			s.Tags[k] = value
+		}
+	}
+
+	columns, err := msgpackArray(object, "columns")
+	if err != nil {
+		return nil, err
+	}
+	for _, column := range columns {
+		name, ok := column.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected column of type %T", column)
+		}
+		s.Columns = append(s.Columns, name)
+	}
+
+	rows, err := msgpackArray(object, "values")
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		values, ok := row.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected row of type %T", row)
+		}
+		for i, value := range values {
+			if i < len(s.Columns) && s.Columns[i] == "time" {
+				if values[i], err = parseTime(value, epoch); err != nil {
+					return nil, fmt.Errorf(
+						"invalid value of column %q in series %q: %v",
+						column(s, i), s.Name, err,
+					)
+				}
+			}
+		}
+		s.Values = append(s.Values, values)
+	}
+
+	return s, nil
+}
+
+// msgpackArray returns the array stored under key, nil if it is missing.
+func msgpackArray(object map[string]interface{}, key string) ([]interface{}, error) {
+	v, ok := object[key]
+	if !ok || v == nil {
+		return nil, nil
+	}
+	array, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected %s of type %T", key, v)
+	}
+	return array, nil
+}
+
+// msgpackField stores the value under key into dst, which points to a
+// string, a bool or an int64. Missing keys are ignored.
+func msgpackField(object map[string]interface{}, key string, dst interface{}) error {
+	v, ok := object[key]
+	if !ok || v == nil {
+		return nil
+	}
+
+	switch t := dst.(type) {
+	case *string:
+		if s, ok := v.(string); ok {
+			*t = s
+			return nil
+		}
+	case *bool:
+		if b, ok := v.(bool); ok {
+			*t = b
+			return nil
+		}
+	case *int64:
+		switch n := v.(type) {
+		case int64:
+			*t = n
+			return nil
+		case uint64:
+			return fmt.Errorf("%s %d out of range", key, n)
+		}
+	}
+
+	return fmt.Errorf("unexpected %s of type %T", key, v)
+}
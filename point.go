@@ -0,0 +1,243 @@
+package influxql
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Point is a single point written with the line protocol.
+//
+//	NewPoint("cpu").
+//		Tag("host", "server01").
+//		Field("usage", 0.64).
+//		Field("cores", 4).
+//		Time(now)
+//
+// is written as
+//
+//	cpu,host=server01 usage=0.64,cores=4i 1577836800000000000
+type Point struct {
+	measurement string
+	tags        map[string]string
+	fieldKeys   []string
+	fields      map[string]interface{}
+	time        time.Time
+	err         error
+}
+
+// NewPoint creates a point of the given measurement.
+func NewPoint(measurement string) *Point {
+	return &Point{
+		measurement: measurement,
+		tags:        map[string]string{},
+		fields:      map[string]interface{}{},
+	}
+}
+
+// Tag sets a tag, tags with an empty value are not written.
+func (p *Point) Tag(key string, value string) *Point {
+	p.tags[key] = value
+	return p
+}
+
+// Field sets a field. The value is a float, a signed or unsigned integer, a
+// string or a bool, named types are accepted by their kind.
+func (p *Point) Field(key string, value interface{}) *Point {
+	v, err := fieldValue(value)
+	if err != nil {
+		if p.err == nil {
+			p.err = fmt.Errorf("Invalid value of field %q: %v.", key, err)
+		}
+		return p
+	}
+
+	if _, ok := p.fields[key]; !ok {
+		p.fieldKeys = append(p.fieldKeys, key)
+	}
+	p.fields[key] = v
+	return p
+}
+
+// Time sets the timestamp, the server time is used when it is not set.
+func (p *Point) Time(t time.Time) *Point {
+	p.time = t
+	return p
+}
+
+// Measurement returns the measurement name.
+func (p *Point) Measurement() string {
+	return p.measurement
+}
+
+// Tags returns a copy of the tags.
+func (p *Point) Tags() map[string]string {
+	tags := make(map[string]string, len(p.tags))
+	for k, v := range p.tags {
+		tags[k] = v
+	}
+	return tags
+}
+
+// Fields returns a copy of the fields, values are float64, int64, uint64,
+// string or bool.
+func (p *Point) Fields() map[string]interface{} {
+	fields := make(map[string]interface{}, len(p.fields))
+	for k, v := range p.fields {
+		fields[k] = v
+	}
+	return fields
+}
+
+// Timestamp returns the timestamp, if it is set.
+func (p *Point) Timestamp() (time.Time, bool) {
+	return p.time, !p.time.IsZero()
+}
+
+func fieldValue(value interface{}) (interface{}, error) {
+	switch t := value.(type) {
+	case float64:
+		if math.IsNaN(t) || math.IsInf(t, 0) {
+			return nil, fmt.Errorf("%v is not supported", t)
+		}
+		return t, nil
+	case int64, uint64, string, bool:
+		return t, nil
+	case nil:
+		return nil, errors.New("null is not supported")
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return fieldValue(rv.Float())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %T", value)
+}
+
+var (
+	measurementEscaper = strings.NewReplacer(`,`, `\,`, ` `, `\ `)
+	keyEscaper         = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `)
+	stringEscaper      = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+)
+
+// checkName rejects names that cannot be written in the line protocol.
+func checkName(kind string, name string) error {
+	if name == "" {
+		return fmt.Errorf("Empty %s.", kind)
+	}
+	if strings.ContainsAny(name, "\n\r") {
+		return fmt.Errorf("Invalid %s %q: line breaks are not allowed.", kind, name)
+	}
+	if strings.HasSuffix(name, `\`) {
+		return fmt.Errorf("Invalid %s %q: trailing backslash.", kind, name)
+	}
+	return nil
+}
+
+// LineProtocol returns the point as a line of the line protocol, without the
+// trailing line break. The timestamp is written with the given precision,
+// RFC3339 means nanoseconds.
+func (p *Point) LineProtocol(precision Precision) (string, error) {
+	line, err := p.appendLine(nil, precision)
+	return string(line), err
+}
+
+func (p *Point) appendLine(dst []byte, precision Precision) ([]byte, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if err := checkName("measurement", p.measurement); err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(p.measurement, "#") {
+		return nil, fmt.Errorf(
+			"Invalid measurement %q: it would be read as a comment.", p.measurement,
+		)
+	}
+	if len(p.fields) == 0 {
+		return nil, fmt.Errorf("Missing fields in point %q.", p.measurement)
+	}
+
+	dst = append(dst, measurementEscaper.Replace(p.measurement)...)
+
+	keys := make([]string, 0, len(p.tags))
+	for k, v := range p.tags {
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := p.tags[k]
+		if err := checkName("tag key", k); err != nil {
+			return nil, err
+		}
+		if err := checkName("tag value", v); err != nil {
+			return nil, err
+		}
+		dst = append(dst, ',')
+		dst = append(dst, keyEscaper.Replace(k)...)
+		dst = append(dst, '=')
+		dst = append(dst, keyEscaper.Replace(v)...)
+	}
+
+	for i, k := range p.fieldKeys {
+		if err := checkName("field key", k); err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			dst = append(dst, ' ')
+		} else {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, keyEscaper.Replace(k)...)
+		dst = append(dst, '=')
+
+		switch v := p.fields[k].(type) {
+		case float64:
+			dst = strconv.AppendFloat(dst, v, 'g', -1, 64)
+		case int64:
+			dst = strconv.AppendInt(dst, v, 10)
+			dst = append(dst, 'i')
+		case uint64:
+			dst = strconv.AppendUint(dst, v, 10)
+			dst = append(dst, 'u')
+		case string:
+			dst = append(dst, '"')
+			dst = append(dst, stringEscaper.Replace(v)...)
+			dst = append(dst, '"')
+		case bool:
+			dst = strconv.AppendBool(dst, v)
+		}
+	}
+
+	if !p.time.IsZero() {
+		if precision == RFC3339 {
+			precision = PrecisionNanosecond
+		}
+		n, err := epoch(p.time, precision)
+		if err != nil {
+			return nil, err
+		}
+		dst = append(dst, ' ')
+		dst = strconv.AppendInt(dst, n, 10)
+	}
+
+	return dst, nil
+}
@@ -0,0 +1,589 @@
+package influxql
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testLevel string
+
+var testTime = time.Date(2020, 1, 1, 0, 0, 1, 123456789, time.UTC)
+
+var pointSamples = []struct {
+	point     *Point
+	precision Precision
+	line      string
+}{
+	{
+		NewPoint("cpu").Field("value", 1.5),
+		RFC3339,
+		`cpu value=1.5`,
+	},
+	{
+		NewPoint("cpu").
+			Tag("region", "eu").
+			Tag("host", "a").
+			Tag("empty", "").
+			Field("usage", 0.64).
+			Field("cores", 4).
+			Field("free", uint32(7)).
+			Field("ok", true).
+			Field("level", testLevel("high")).
+			Time(testTime),
+		RFC3339,
+		`cpu,host=a,region=eu usage=0.64,cores=4i,free=7u,ok=true,level="high" 1577836801123456789`,
+	},
+	{
+		NewPoint("cpu load,1").
+			Tag("host name", "a=b,c").
+			Tag("path", `C:\dir`).
+			Field("field key=1,2", `say "hi" \o/`),
+		RFC3339,
+		`cpu\ load\,1,host\ name=a\=b\,c,path=C:\dir field\ key\=1\,2="say \"hi\" \\o/"`,
+	},
+	{
+		NewPoint("m=1").Field("big", 1e21).Field("small", float32(0.25)).Field("neg", -3),
+		RFC3339,
+		`m=1 big=1e+21,small=0.25,neg=-3i`,
+	},
+	{
+		NewPoint("cpu").Field("value", 1).Field("value", 2).Time(testTime),
+		PrecisionMillisecond,
+		`cpu value=2i 1577836801123`,
+	},
+	{
+		NewPoint("cpu").Field("value", 1).Time(testTime),
+		PrecisionSecond,
+		`cpu value=1i 1577836801`,
+	},
+	{
+		NewPoint("cpu").Field("value", 1).Time(time.Unix(-1, 500)),
+		PrecisionSecond,
+		`cpu value=1i -1`,
+	},
+}
+
+func TestPointLineProtocol(t *testing.T) {
+	for _, sample := range pointSamples {
+		line, err := sample.point.LineProtocol(sample.precision)
+		if assert.NoError(t, err, sample.line) {
+			assert.Equal(t, sample.line, line)
+		}
+	}
+}
+
+func TestPointLineProtocolErrors(t *testing.T) {
+	tests := []struct {
+		point *Point
+		err   string
+	}{
+		{NewPoint("cpu"), `Missing fields in point "cpu".`},
+		{NewPoint("").Field("v", 1), "Empty measurement."},
+		{NewPoint("#cpu").Field("v", 1), `Invalid measurement "#cpu": it would be read as a comment.`},
+		{NewPoint("cpu").Tag("host", "a\nb").Field("v", 1), `Invalid tag value "a\nb": line breaks are not allowed.`},
+		{NewPoint("cpu").Tag("", "a").Field("v", 1), "Empty tag key."},
+		{NewPoint("cpu").Tag("host", `a\`).Field("v", 1), `Invalid tag value "a\\": trailing backslash.`},
+		{NewPoint("cpu").Field("v", math.NaN()), `Invalid value of field "v": NaN is not supported.`},
+		{NewPoint("cpu").Field("v", nil), `Invalid value of field "v": null is not supported.`},
+		{NewPoint("cpu").Field("v", []int{1}), `Invalid value of field "v": unsupported type []int.`},
+	}
+
+	for _, test := range tests {
+		_, err := test.point.LineProtocol(RFC3339)
+		assert.EqualError(t, err, test.err)
+	}
+
+	_, err := NewPoint("cpu").Field("v", 1).Time(testTime).LineProtocol("h")
+	assert.EqualError(t, err, `Unsupported precision "h".`)
+}
+
+func TestWriter(t *testing.T) {
+	var (
+		req  *http.Request
+		body []byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	w := NewClient(srv.URL).BasicAuth("user", "secret").
+		Writer("telegraf").
+		RetentionPolicy("autogen").
+		Precision(PrecisionMillisecond).
+		Consistency(ConsistencyQuorum)
+
+	err := w.Write(context.Background(),
+		NewPoint("cpu").Tag("host", "a").Field("value", 1.5).Time(testTime),
+		NewPoint("mem").Field("free", 10),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Equal(t, "/write", req.URL.Path)
+	assert.Equal(t, "telegraf", req.URL.Query().Get("db"))
+	assert.Equal(t, "autogen", req.URL.Query().Get("rp"))
+	assert.Equal(t, "ms", req.URL.Query().Get("precision"))
+	assert.Equal(t, "quorum", req.URL.Query().Get("consistency"))
+	username, _, _ := req.BasicAuth()
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "cpu,host=a value=1.5 1577836801123\nmem free=10i\n", string(body))
+
+	err = w.Write(context.Background(), NewPoint("cpu"))
+	assert.EqualError(t, err, `Point 1: Missing fields in point "cpu".`)
+}
+
+func TestWriterServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"database not found: \"nope\""}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	err := NewClient(srv.URL).Writer("nope").Write(
+		context.Background(), NewPoint("cpu").Field("value", 1),
+	)
+	assert.Equal(t, &ServerError{
+		StatusCode: http.StatusNotFound,
+		Message:    `database not found: "nope"`,
+	}, err)
+}
+
+func TestParseLineProtocolRoundTrip(t *testing.T) {
+	for _, sample := range pointSamples {
+		points, err := ParseLineProtocol([]byte(sample.line), sample.precision)
+		if !assert.NoError(t, err, sample.line) || !assert.Len(t, points, 1) {
+			continue
+		}
+
+		line, err := points[0].LineProtocol(sample.precision)
+		assert.NoError(t, err)
+		assert.Equal(t, sample.line, line)
+	}
+}
+
+func TestParseLineProtocol(t *testing.T) {
+	input := "# exported by agent\n" +
+		"\n" +
+		"  cpu,host=a,region=eu usage=0.5,cores=4i,free=7u,ok=T,off=FALSE 1577836801123\r\n" +
+		"weather,city=New\\ York temp=-1.5e1,note=\"line one\nline \\\"two\\\" \\\\ \\n\"\n" +
+		"disk,path=C:\\dir,a\\=b=c\\,d used=.5   \n"
+
+	points, err := ParseLineProtocol([]byte(input), PrecisionMillisecond)
+	require.NoError(t, err)
+	require.Len(t, points, 3)
+
+	assert.Equal(t, "cpu", points[0].Measurement())
+	assert.Equal(t, map[string]string{"host": "a", "region": "eu"}, points[0].Tags())
+	assert.Equal(t, map[string]interface{}{
+		"usage": 0.5,
+		"cores": int64(4),
+		"free":  uint64(7),
+		"ok":    true,
+		"off":   false,
+	}, points[0].Fields())
+	ts, ok := points[0].Timestamp()
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2020, 1, 1, 0, 0, 1, 123000000, time.UTC), ts)
+
+	assert.Equal(t, map[string]string{"city": "New York"}, points[1].Tags())
+	assert.Equal(t, map[string]interface{}{
+		"temp": -15.0,
+		"note": "line one\nline \"two\" \\ \\n",
+	}, points[1].Fields())
+	_, ok = points[1].Timestamp()
+	assert.False(t, ok)
+
+	assert.Equal(t, map[string]string{"path": `C:\dir`, "a=b": "c,d"}, points[2].Tags())
+	assert.Equal(t, map[string]interface{}{"used": 0.5}, points[2].Fields())
+}
+
+func TestParseLineProtocolErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"cpu", "line 1, column 4: missing fields"},
+		{",host=a v=1", "line 1, column 1: missing measurement"},
+		{"cpu,host v=1", `line 1, column 9: missing value of tag "host"`},
+		{"cpu,host= v=1", `line 1, column 10: missing value of tag "host"`},
+		{"cpu,=a v=1", "line 1, column 5: missing tag key"},
+		{"cpu ", "line 1, column 5: missing field key"},
+		{"cpu v", `line 1, column 6: missing value of field "v"`},
+		{"cpu v=", `line 1, column 7: missing value of field "v"`},
+		{"cpu v=abc", `line 1, column 7: invalid value "abc" of field "v"`},
+		{"cpu v=1.2.3", `line 1, column 7: invalid float "1.2.3" of field "v"`},
+		{"cpu v=1.5i", `line 1, column 7: invalid integer "1.5i" of field "v"`},
+		{"cpu v=-1u", `line 1, column 7: invalid unsigned integer "-1u" of field "v"`},
+		{"cpu v=1e999", `line 1, column 7: invalid float "1e999" of field "v"`},
+		{"cpu v=0x1p-2", `line 1, column 7: invalid float "0x1p-2" of field "v"`},
+		{"cpu v=0x1_0p0", `line 1, column 7: invalid float "0x1_0p0" of field "v"`},
+		{"cpu v=1_000.5", `line 1, column 7: invalid float "1_000.5" of field "v"`},
+		{"cpu v=+1.5", `line 1, column 7: invalid float "+1.5" of field "v"`},
+		{"cpu v=\"a", `line 1, column 7: unterminated string value of field "v"`},
+		{"cpu v=\"a\"b", `line 1, column 10: unexpected 'b' after fields`},
+		{"cpu v=1 abc", `line 1, column 9: invalid timestamp "abc"`},
+		{"cpu v=1 1 2", "line 1, column 11: unexpected text after timestamp"},
+		{"cpu v=1\n# ok\ncpu v=\"a\nb\" 1\nmem", "line 5, column 4: missing fields"},
+		{"cpu v=1 99999999999999999", `line 1, column 9: timestamp "99999999999999999" out of range`},
+	}
+
+	for _, test := range tests {
+		_, err := ParseLineProtocol([]byte(test.input), PrecisionSecond)
+		assert.EqualError(t, err, test.err, test.input)
+		if _, ok := err.(*ParseError); !ok {
+			t.Errorf("%q: expected a *ParseError, got %T", test.input, err)
+		}
+	}
+}
+
+func FuzzLineProtocol(f *testing.F) {
+	for _, sample := range pointSamples {
+		f.Add(sample.line)
+	}
+	f.Add("weather,city=New\\ York note=\"a\nb\" 1\n# comment\ncpu v=1")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		points, err := ParseLineProtocol([]byte(input), RFC3339)
+		if err != nil {
+			return
+		}
+
+		for _, point := range points {
+			line, err := point.LineProtocol(RFC3339)
+			if err != nil {
+				continue
+			}
+
+			parsed, err := ParseLineProtocol([]byte(line), RFC3339)
+			if err != nil {
+				t.Fatalf("%q: %v", line, err)
+			}
+			if len(parsed) != 1 {
+				t.Fatalf("%q: parsed %d points", line, len(parsed))
+			}
+			assert.Equal(t, point.Measurement(), parsed[0].Measurement(), line)
+			assert.Equal(t, point.Tags(), parsed[0].Tags(), line)
+			assert.Equal(t, point.Fields(), parsed[0].Fields(), line)
+		}
+	})
+}
+
+type writeServer struct {
+	mu       sync.Mutex
+	bodies   []string
+	statuses []int
+	messages []string
+}
+
+// newWriteServer starts a writeServer replying with the given statuses.
+func newWriteServer(t *testing.T, statuses []int, messages []string) (*httptest.Server, *writeServer) {
+	ws := &writeServer{statuses: statuses, messages: messages}
+	srv := httptest.NewServer(ws)
+	t.Cleanup(srv.Close)
+	return srv, ws
+}
+
+// ServeHTTP records the decompressed bodies of writes, it replies with the
+// given statuses in turn and then with 204.
+func (ws *writeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var reader io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		reader = gz
+	}
+	body, _ := io.ReadAll(reader)
+
+	ws.mu.Lock()
+	ws.bodies = append(ws.bodies, string(body))
+	status, message := http.StatusNoContent, ""
+	if len(ws.statuses) > 0 {
+		status, message = ws.statuses[0], ws.messages[0]
+		ws.statuses, ws.messages = ws.statuses[1:], ws.messages[1:]
+	}
+	ws.mu.Unlock()
+
+	w.WriteHeader(status)
+	if message != "" {
+		_, _ = w.Write([]byte(`{"error":"` + message + `"}`))
+	}
+}
+
+func (ws *writeServer) requests() []string {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return append([]string{}, ws.bodies...)
+}
+
+func TestBatchWriterSize(t *testing.T) {
+	srv, ws := newWriteServer(t, nil, nil)
+	b := NewClient(srv.URL).Writer("db").Batch().Size(2).Interval(0)
+
+	ctx := context.Background()
+	require.NoError(t, b.Write(ctx, NewPoint("cpu").Field("v", 1)))
+	assert.Empty(t, ws.requests())
+	require.NoError(t, b.Write(ctx, NewPoint("cpu").Field("v", 2)))
+	assert.Equal(t, []string{"cpu v=1i\ncpu v=2i\n"}, ws.requests())
+
+	require.NoError(t, b.Write(ctx, NewPoint("cpu").Field("v", 3)))
+	require.NoError(t, b.Close())
+	assert.Equal(t, []string{"cpu v=1i\ncpu v=2i\n", "cpu v=3i\n"}, ws.requests())
+
+	assert.Equal(t, ErrWriterClosed, b.Write(ctx, NewPoint("cpu").Field("v", 4)))
+	assert.NoError(t, b.Close())
+
+	err := NewClient(srv.URL).Writer("db").Batch().Write(ctx, NewPoint("cpu"))
+	assert.EqualError(t, err, `Point 1: Missing fields in point "cpu".`)
+}
+
+func TestBatchWriterInterval(t *testing.T) {
+	srv, ws := newWriteServer(t, nil, nil)
+	b := NewClient(srv.URL).Writer("db").Batch().Interval(10 * time.Millisecond)
+	defer b.Close()
+
+	require.NoError(t, b.Write(context.Background(), NewPoint("cpu").Field("v", 1)))
+	assert.Eventually(t, func() bool {
+		return len(ws.requests()) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"cpu v=1i\n"}, ws.requests())
+}
+
+func TestBatchWriterRetry(t *testing.T) {
+	srv, ws := newWriteServer(t,
+		[]int{http.StatusServiceUnavailable, http.StatusInternalServerError},
+		[]string{"overloaded", "timeout"},
+	)
+	b := NewClient(srv.URL).Writer("db").Batch().
+		Interval(0).
+		Backoff(time.Millisecond, 2*time.Millisecond)
+
+	require.NoError(t, b.Write(context.Background(), NewPoint("cpu").Field("v", 1)))
+	require.NoError(t, b.Flush(context.Background()))
+	assert.Equal(t, []string{"cpu v=1i\n", "cpu v=1i\n", "cpu v=1i\n"}, ws.requests())
+
+	srv, ws = newWriteServer(t,
+		[]int{http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+		[]string{"overloaded", "overloaded"},
+	)
+	b = NewClient(srv.URL).Writer("db").Batch().
+		Interval(0).
+		Retries(1).
+		Backoff(time.Millisecond, time.Millisecond)
+
+	require.NoError(t, b.Write(context.Background(), NewPoint("cpu").Field("v", 1)))
+	err := b.Flush(context.Background())
+	assert.Equal(t, &ServerError{StatusCode: http.StatusServiceUnavailable, Message: "overloaded"}, err)
+	assert.Len(t, ws.requests(), 2)
+}
+
+func TestBatchWriterPartialWrite(t *testing.T) {
+	srv, ws := newWriteServer(t,
+		[]int{http.StatusBadRequest},
+		[]string{`partial write: field type conflict: input field \"v\" on measurement \"cpu\" is type integer, already exists as type float dropped=1`},
+	)
+	b := NewClient(srv.URL).Writer("db").Batch().Interval(0).Gzip(false)
+
+	require.NoError(t, b.Write(context.Background(),
+		NewPoint("cpu").Field("v", 1.5), NewPoint("cpu").Field("v", 1),
+	))
+	err := b.Flush(context.Background())
+
+	partial, ok := err.(*PartialWriteError)
+	require.True(t, ok, "%T", err)
+	assert.Equal(t, 1, partial.Dropped)
+	assert.Len(t, ws.requests(), 1)
+}
+
+func TestBatchWriterCanceledFlush(t *testing.T) {
+	srv, ws := newWriteServer(t, nil, nil)
+	b := NewClient(srv.URL).Writer("db").Batch().Size(2).Interval(0)
+
+	require.NoError(t, b.Write(context.Background(), NewPoint("cpu").Field("v", 1)))
+
+	// The batch is full but the caller that fills it gives up, the points of
+	// both writes are kept for the next flush.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := b.Write(ctx, NewPoint("cpu").Field("v", 2))
+	assert.True(t, errors.Is(err, context.Canceled), "%v", err)
+	assert.Empty(t, ws.requests())
+
+	require.NoError(t, b.Write(context.Background(), NewPoint("cpu").Field("v", 3)))
+	require.NoError(t, b.Close())
+	assert.Equal(t, []string{"cpu v=1i\ncpu v=2i\n", "cpu v=3i\n"}, ws.requests())
+}
+
+func TestBatchWriterDropped(t *testing.T) {
+	srv, ws := newWriteServer(t,
+		[]int{http.StatusBadRequest, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+		[]string{"unable to parse", "overloaded", "overloaded"},
+	)
+
+	var reported []error
+	b := NewClient(srv.URL).Writer("db").Batch().
+		Size(2).
+		Interval(0).
+		Retries(0).
+		OnError(func(err error) { reported = append(reported, err) })
+
+	ctx := context.Background()
+	require.NoError(t, b.Write(ctx, NewPoint("cpu").Field("v", 1)))
+	err := b.Write(ctx, NewPoint("cpu").Field("v", 2))
+	badRequest := &DroppedPointsError{
+		Points: 2,
+		Err:    &ServerError{StatusCode: http.StatusBadRequest, Message: "unable to parse"},
+	}
+	assert.Equal(t, badRequest, err)
+
+	// The unavailable server keeps the batch until Close gives up on it.
+	require.NoError(t, b.Write(ctx, NewPoint("cpu").Field("v", 3)))
+	err = b.Write(ctx, NewPoint("cpu").Field("v", 4))
+	unavailable := &ServerError{StatusCode: http.StatusServiceUnavailable, Message: "overloaded"}
+	assert.Equal(t, unavailable, err)
+
+	require.NoError(t, b.Write(ctx, NewPoint("cpu").Field("v", 5)))
+	err = b.Close()
+	dropped := &DroppedPointsError{Points: 3, Err: unavailable}
+	assert.Equal(t, dropped, err)
+
+	assert.Equal(t, []error{badRequest, dropped}, reported)
+	assert.Len(t, ws.requests(), 3)
+}
+
+func TestBatchWriterMaxPending(t *testing.T) {
+	srv, ws := newWriteServer(t,
+		[]int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+		[]string{"overloaded", "overloaded", "overloaded"},
+	)
+
+	var reported []error
+	b := NewClient(srv.URL).Writer("db").Batch().
+		Size(2).
+		Interval(0).
+		Retries(0).
+		MaxPending(4).
+		OnError(func(err error) { reported = append(reported, err) })
+
+	// Each full batch fails, the oldest one is dropped once more than 4
+	// points are kept.
+	ctx := context.Background()
+	unavailable := &ServerError{StatusCode: http.StatusServiceUnavailable, Message: "overloaded"}
+	for i := 1; i <= 6; i++ {
+		err := b.Write(ctx, NewPoint("cpu").Field("v", i))
+		if i%2 == 0 {
+			assert.Equal(t, unavailable, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+	assert.Equal(t, []error{&DroppedPointsError{Points: 2, Err: unavailable}}, reported)
+
+	require.NoError(t, b.Close())
+	assert.Equal(t, []string{
+		"cpu v=1i\ncpu v=2i\n",
+		"cpu v=1i\ncpu v=2i\n",
+		"cpu v=1i\ncpu v=2i\n",
+		"cpu v=3i\ncpu v=4i\n",
+		"cpu v=5i\ncpu v=6i\n",
+	}, ws.requests())
+}
+
+func TestBatchWriterServerDown(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	b := NewClient("http://"+addr).Writer("db").Batch().
+		Interval(0).
+		Retries(1).
+		Backoff(time.Millisecond, time.Millisecond)
+
+	ctx := context.Background()
+	require.NoError(t, b.Write(ctx, NewPoint("cpu").Field("v", 1)))
+	err = b.Flush(ctx)
+	require.Error(t, err)
+	_, isDropped := err.(*DroppedPointsError)
+	assert.False(t, isDropped, "%v", err)
+
+	// The server comes back on the same address.
+	listener, err = net.Listen("tcp", addr)
+	require.NoError(t, err)
+	ws := &writeServer{}
+	srv := httptest.NewUnstartedServer(ws)
+	srv.Listener = listener
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	require.NoError(t, b.Write(ctx, NewPoint("cpu").Field("v", 2)))
+	require.NoError(t, b.Close())
+	assert.Equal(t, []string{"cpu v=1i\n", "cpu v=2i\n"}, ws.requests())
+}
+
+func TestBatchWriterTimeout(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	b := NewClient(srv.URL).
+		HTTPClient(&http.Client{Timeout: 20 * time.Millisecond}).
+		Writer("db").Batch().
+		Interval(0).
+		Backoff(time.Millisecond, time.Millisecond)
+
+	require.NoError(t, b.Write(context.Background(), NewPoint("cpu").Field("v", 1)))
+	require.NoError(t, b.Flush(context.Background()))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+}
+
+func TestBatchWriterConcurrent(t *testing.T) {
+	srv, ws := newWriteServer(t, nil, nil)
+	b := NewClient(srv.URL).Writer("db").Batch().Size(7).Interval(time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				assert.NoError(t, b.Write(context.Background(),
+					NewPoint("cpu").Tag("worker", strconv.Itoa(i)).Field("v", j),
+				))
+			}
+		}(i)
+	}
+	wg.Wait()
+	require.NoError(t, b.Close())
+
+	lines := 0
+	for _, body := range ws.requests() {
+		lines += strings.Count(body, "\n")
+	}
+	assert.Equal(t, 400, lines)
+}
@@ -0,0 +1,57 @@
+package influxql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Regex represents a regular expression literal, like /^cpu/. It is accepted
+// everywhere InfluxQL allows a regex: FROM, WHERE, GROUP BY, function
+// arguments, SHOW ... WITH KEY and WITH MEASUREMENT.
+type Regex string
+
+// Build satisfies Builder.
+func (r Regex) Build() (string, error) {
+	if _, err := regexp.Compile(string(r)); err != nil {
+		return "", fmt.Errorf("Invalid regex %q: %v", string(r), err)
+	}
+	return "/" + escapeRegex(string(r)) + "/", nil
+}
+
+// escapeRegex escapes the / delimiter. Slashes that are already escaped are
+// kept as is, so both a/b and a\/b are rendered as a\/b, and a trailing
+// backslash is doubled so it cannot escape the closing delimiter.
+func escapeRegex(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 == len(s) {
+				b.WriteString(`\\`)
+				continue
+			}
+			b.WriteByte(s[i])
+			i++
+			b.WriteByte(s[i])
+		case '/':
+			b.WriteString(`\/`)
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// QuoteMeta returns a regex that matches exactly one of the given strings,
+// all regex metacharacters in them are escaped.
+func QuoteMeta(values ...string) Regex {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, regexp.QuoteMeta(value))
+	}
+	if len(quoted) == 1 {
+		return Regex("^" + quoted[0] + "$")
+	}
+	return Regex("^(?:" + strings.Join(quoted, "|") + ")$")
+}
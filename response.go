@@ -0,0 +1,365 @@
+package influxql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Response is the decoded body of a /query response.
+type Response struct {
+	Results []*Result `json:"results"`
+	// Err is the error of the whole request, like a parse error.
+	Err string `json:"error,omitempty"`
+}
+
+// Error returns the error of the request or of the first failed statement,
+// nil when every statement succeeded.
+func (r *Response) Error() error {
+	if r.Err != "" {
+		return &StatementError{StatementID: -1, Message: r.Err}
+	}
+	for _, result := range r.Results {
+		if err := result.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Result is the outcome of a single statement.
+type Result struct {
+	StatementID int        `json:"statement_id"`
+	Series      []*Series  `json:"series,omitempty"`
+	Messages    []*Message `json:"messages,omitempty"`
+	Partial     bool       `json:"partial,omitempty"`
+	Err         string     `json:"error,omitempty"`
+}
+
+// Error returns the error of the statement, if any.
+func (r *Result) Error() error {
+	if r.Err == "" {
+		return nil
+	}
+	return &StatementError{StatementID: r.StatementID, Message: r.Err}
+}
+
+// Series is a list of rows sharing a measurement name and a set of tags.
+//
+// Values hold nil, bool, string, int64, float64 or, for integers that do not
+// fit in int64, uint64. Values of the time column are time.Time.
+type Series struct {
+	Name    string            `json:"name,omitempty"`
+	Tags    map[string]string `json:"tags,omitempty"`
+	Columns []string          `json:"columns"`
+	Values  [][]interface{}   `json:"values,omitempty"`
+	Partial bool              `json:"partial,omitempty"`
+}
+
+// Rows returns the values of the series as rows.
+func (s *Series) Rows() []Row {
+	rows := make([]Row, 0, len(s.Values))
+	for _, values := range s.Values {
+		rows = append(rows, Row{series: s, Values: values})
+	}
+	return rows
+}
+
+// Row is a single row of a series.
+type Row struct {
+	series *Series
+	Values []interface{}
+}
+
+// Name returns the measurement name of the row.
+func (r Row) Name() string {
+	return r.series.Name
+}
+
+// Tags returns the tags of the series the row belongs to.
+func (r Row) Tags() map[string]string {
+	return r.series.Tags
+}
+
+// Columns returns the column names of the row.
+func (r Row) Columns() []string {
+	return r.series.Columns
+}
+
+// Value returns the value of the column, or of the series tag, with the
+// given name.
+func (r Row) Value(name string) (interface{}, bool) {
+	for i, column := range r.series.Columns {
+		if column == name && i < len(r.Values) {
+			return r.Values[i], true
+		}
+	}
+	if tag, ok := r.series.Tags[name]; ok {
+		return tag, true
+	}
+	return nil, false
+}
+
+// Time returns the value of the time column.
+func (r Row) Time() (time.Time, bool) {
+	v, ok := r.Value("time")
+	if !ok {
+		return time.Time{}, false
+	}
+	t, ok := v.(time.Time)
+	return t, ok
+}
+
+// Message is an informational message attached to a statement result.
+type Message struct {
+	Level string `json:"level"`
+	Text  string `json:"text"`
+}
+
+// StatementError is the error reported by the server for a statement.
+type StatementError struct {
+	// StatementID is -1 when the error concerns the whole request.
+	StatementID int
+	Message     string
+}
+
+func (e *StatementError) Error() string {
+	if e.StatementID < 0 {
+		return "influxdb: " + e.Message
+	}
+	return fmt.Sprintf("influxdb: statement %d: %s", e.StatementID, e.Message)
+}
+
+// DecodeResponse decodes a JSON /query response. The epoch is the precision
+// the query was sent with, it is used to convert epoch times. Chunked
+// responses are read to the end and their partial series are joined.
+func DecodeResponse(r io.Reader, epoch Precision) (*Response, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	var resp *Response
+	for {
+		chunk, err := decodeChunk(decoder, epoch)
+		if err == io.EOF {
+			if resp != nil {
+				return resp, nil
+			}
+			return nil, fmt.Errorf("Unable to decode response: %v.", err)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if resp == nil {
+			resp = chunk
+		} else {
+			resp.merge(chunk)
+		}
+	}
+}
+
+// decodeChunk decodes the next JSON object of a response, it returns io.EOF
+// at the end of the stream.
+func decodeChunk(decoder *json.Decoder, epoch Precision) (*Response, error) {
+	chunk := &Response{}
+	if err := decoder.Decode(chunk); err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, fmt.Errorf("Unable to decode response: %v.", err)
+	}
+
+	for _, result := range chunk.Results {
+		for _, series := range result.Series {
+			if err := normalizeSeries(series, epoch); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return chunk, nil
+}
+
+// merge adds the results of the next chunk of a chunked response.
+func (r *Response) merge(chunk *Response) {
+	if chunk.Err != "" {
+		r.Err = chunk.Err
+	}
+
+	for _, result := range chunk.Results {
+		n := len(r.Results)
+		if n == 0 || r.Results[n-1].StatementID != result.StatementID ||
+			!r.Results[n-1].Partial {
+			r.Results = append(r.Results, result)
+			continue
+		}
+
+		last := r.Results[n-1]
+		for _, series := range result.Series {
+			if k := len(last.Series); k > 0 && last.Series[k-1].continuedBy(series) {
+				last.Series[k-1].join(series)
+			} else {
+				last.Series = append(last.Series, series)
+			}
+		}
+		last.Messages = append(last.Messages, result.Messages...)
+		last.Partial = result.Partial
+		if result.Err != "" {
+			last.Err = result.Err
+		}
+	}
+}
+
+// continuedBy tells whether next holds the following rows of the partial
+// series s.
+func (s *Series) continuedBy(next *Series) bool {
+	if !s.Partial || s.Name != next.Name || !equalTags(s.Tags, next.Tags) ||
+		len(s.Columns) != len(next.Columns) {
+		return false
+	}
+	for i := range s.Columns {
+		if s.Columns[i] != next.Columns[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// join appends the rows of the continuation of s.
+func (s *Series) join(next *Series) {
+	s.Values = append(s.Values, next.Values...)
+	s.Partial = next.Partial
+}
+
+// normalizeSeries converts decoded JSON values to the types documented on
+// Series.
+func normalizeSeries(s *Series, epoch Precision) error {
+	for _, values := range s.Values {
+		for i, v := range values {
+			var err error
+			if i < len(s.Columns) && s.Columns[i] == "time" {
+				values[i], err = parseTime(v, epoch)
+			} else {
+				values[i], err = normalizeValue(v)
+			}
+			if err != nil {
+				return fmt.Errorf(
+					"Invalid value of column %q in series %q: %v.",
+					column(s, i), s.Name, err,
+				)
+			}
+		}
+	}
+	return nil
+}
+
+func column(s *Series, i int) string {
+	if i < len(s.Columns) {
+		return s.Columns[i]
+	}
+	return strconv.Itoa(i)
+}
+
+func normalizeValue(v interface{}) (interface{}, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return v, nil
+	}
+	return parseNumber(string(n))
+}
+
+// parseNumber returns s as an int64, a uint64 or a float64.
+func parseNumber(s string) (interface{}, error) {
+	if !strings.ContainsAny(s, ".eE") {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, nil
+		}
+		if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return u, nil
+		}
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// parseTime converts an RFC3339 string or an epoch number to time.Time. Its
+// errors are capitalized but have no period, callers quote them at the end
+// of their own.
+func parseTime(v interface{}, epoch Precision) (interface{}, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case time.Time:
+		return t, nil
+	case string:
+		return time.Parse(time.RFC3339Nano, t)
+	case json.Number:
+		n, err := parseNumber(string(t))
+		if err != nil {
+			return nil, err
+		}
+		return parseTime(n, epoch)
+	case int64:
+		return epochTime(t, epoch)
+	case uint64:
+		if t > math.MaxInt64 {
+			return nil, fmt.Errorf("Epoch %d is out of range", t)
+		}
+		return epochTime(int64(t), epoch)
+	case float64:
+		if t != math.Trunc(t) || math.Abs(t) > math.MaxInt64 {
+			return nil, fmt.Errorf("Invalid epoch %v", t)
+		}
+		return epochTime(int64(t), epoch)
+	}
+	return nil, fmt.Errorf("Unexpected time %v of type %T", v, v)
+}
+
+func epochTime(n int64, epoch Precision) (time.Time, error) {
+	if epoch == RFC3339 {
+		epoch = PrecisionNanosecond
+	}
+	unit, ok := precisionUnits[epoch]
+	if !ok {
+		return time.Time{}, fmt.Errorf("Unsupported precision %q", string(epoch))
+	}
+	if n > math.MaxInt64/int64(unit) || n < math.MinInt64/int64(unit) {
+		return time.Time{}, fmt.Errorf(
+			"Epoch %d is out of the range of times in %s", n, string(epoch),
+		)
+	}
+	return time.Unix(0, n*int64(unit)).UTC(), nil
+}
+
+// Query executes the statement built by b and decodes the response in the
+// format the server replied with. When the request or a statement fails, the
+// error is returned along with the response, so the results of the other
+// statements are still available.
+func (c *Client) Query(ctx context.Context, b Builder) (*Response, error) {
+	httpResp, err := c.exec(ctx, b, string(c.format))
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	decode := DecodeResponse
+	mediaType, _, _ := mime.ParseMediaType(httpResp.Header.Get("Content-Type"))
+	switch Format(mediaType) {
+	case CSV, "text/csv":
+		decode = DecodeCSVResponse
+	case MessagePack:
+		decode = DecodeMsgpackResponse
+	}
+
+	resp, err := decode(httpResp.Body, c.epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, resp.Error()
+}
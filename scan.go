@@ -0,0 +1,249 @@
+package influxql
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// structFields maps column names to the index paths of the fields of t. A
+// field is named by its `influx` tag or by its name, fields tagged with "-"
+// are skipped and embedded structs are flattened.
+func structFields(t reflect.Type) map[string][]int {
+	fields := map[string][]int{}
+
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			key := strings.Split(field.Tag.Get("influx"), ",")[0]
+			if key == "-" {
+				continue
+			}
+
+			path := append(append([]int{}, index...), i)
+			if field.Anonymous && key == "" && field.Type.Kind() == reflect.Struct &&
+				field.Type != timeType {
+				walk(field.Type, path)
+				continue
+			}
+			if field.PkgPath != "" {
+				continue
+			}
+
+			if key == "" {
+				key = field.Name
+			}
+			if _, ok := fields[key]; !ok {
+				fields[key] = path
+			}
+		}
+	}
+	walk(t, nil)
+
+	return fields
+}
+
+// Scan appends the rows of the series to the slice dst points to, see
+// ScanSeries.
+func (s *Series) Scan(dst interface{}) error {
+	return ScanSeries([]*Series{s}, dst)
+}
+
+// Scan appends the rows of all series of the result to the slice dst points
+// to, see ScanSeries.
+func (r *Result) Scan(dst interface{}) error {
+	if err := r.Error(); err != nil {
+		return err
+	}
+	return ScanSeries(r.Series, dst)
+}
+
+// ScanSeries appends the rows of the series to dst, which is a pointer to a
+// slice of structs or of pointers to structs.
+//
+// Columns and the series tags set by GROUP BY are assigned to the fields
+// named by their `influx` tag, or by their name when they have no tag. The
+// name of a column is the alias given with F.As, or the lowercased function
+// name otherwise. The time column is assigned to a time.Time field and tags
+// to string fields. Null values can only be assigned to pointer and
+// interface fields, which are then left nil.
+//
+// Every column must match a field, series tags without a matching field are
+// ignored.
+func ScanSeries(series []*Series, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("Scan destination must be a pointer to a slice, got %T.", dst)
+	}
+
+	slice := rv.Elem()
+	elemType := slice.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf(
+			"Scan destination must be a slice of structs, got %T.", dst,
+		)
+	}
+
+	fields := structFields(structType)
+
+	for _, s := range series {
+		columns := make([][]int, len(s.Columns))
+		for i, column := range s.Columns {
+			path, ok := fields[column]
+			if !ok {
+				return fmt.Errorf(
+					"Column %q of series %q has no matching field in %s.",
+					column, s.Name, structType,
+				)
+			}
+			columns[i] = path
+		}
+
+		for _, values := range s.Values {
+			elem := reflect.New(structType).Elem()
+
+			for tag, v := range s.Tags {
+				path, ok := fields[tag]
+				if !ok {
+					continue
+				}
+				if err := assign(elem.FieldByIndex(path), v); err != nil {
+					return fmt.Errorf(
+						"Unable to scan tag %q of series %q: %v.", tag, s.Name, err,
+					)
+				}
+			}
+
+			for i, v := range values {
+				if i >= len(columns) {
+					break
+				}
+				if err := assign(elem.FieldByIndex(columns[i]), v); err != nil {
+					return fmt.Errorf(
+						"Unable to scan column %q of series %q: %v.",
+						s.Columns[i], s.Name, err,
+					)
+				}
+			}
+
+			if elemType.Kind() == reflect.Ptr {
+				elem = elem.Addr()
+			}
+			slice = reflect.Append(slice, elem)
+		}
+	}
+
+	rv.Elem().Set(slice)
+	return nil
+}
+
+// assign stores a decoded value into the field, converting numbers between
+// kinds when no precision is lost.
+func assign(field reflect.Value, v interface{}) error {
+	if v == nil {
+		switch field.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+		return fmt.Errorf("cannot assign null to %s, use a pointer", field.Type())
+	}
+
+	if field.Kind() == reflect.Ptr {
+		ptr := reflect.New(field.Type().Elem())
+		if err := assign(ptr.Elem(), v); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+
+	src := reflect.ValueOf(v)
+	if src.Type().AssignableTo(field.Type()) {
+		field.Set(src)
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch t := v.(type) {
+		case int64:
+			n = t
+		case uint64:
+			if t > math.MaxInt64 {
+				return fmt.Errorf("%d overflows %s", t, field.Type())
+			}
+			n = int64(t)
+		case float64:
+			if t != math.Trunc(t) || t < math.MinInt64 || t >= math.MaxInt64 {
+				return fmt.Errorf("cannot assign %v to %s", t, field.Type())
+			}
+			n = int64(t)
+		default:
+			return mismatch(field, v)
+		}
+		if field.OverflowInt(n) {
+			return fmt.Errorf("%d overflows %s", n, field.Type())
+		}
+		field.SetInt(n)
+		return nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		switch t := v.(type) {
+		case int64:
+			if t < 0 {
+				return fmt.Errorf("%d overflows %s", t, field.Type())
+			}
+			n = uint64(t)
+		case uint64:
+			n = t
+		case float64:
+			if t != math.Trunc(t) || t < 0 || t >= math.MaxUint64 {
+				return fmt.Errorf("cannot assign %v to %s", t, field.Type())
+			}
+			n = uint64(t)
+		default:
+			return mismatch(field, v)
+		}
+		if field.OverflowUint(n) {
+			return fmt.Errorf("%d overflows %s", n, field.Type())
+		}
+		field.SetUint(n)
+		return nil
+
+	case reflect.Float32, reflect.Float64:
+		switch t := v.(type) {
+		case int64:
+			field.SetFloat(float64(t))
+		case uint64:
+			field.SetFloat(float64(t))
+		case float64:
+			field.SetFloat(t)
+		default:
+			return mismatch(field, v)
+		}
+		return nil
+	}
+
+	if src.Type().ConvertibleTo(field.Type()) && src.Kind() == field.Kind() {
+		field.Set(src.Convert(field.Type()))
+		return nil
+	}
+
+	return mismatch(field, v)
+}
+
+func mismatch(field reflect.Value, v interface{}) error {
+	return fmt.Errorf("cannot assign %T to %s", v, field.Type())
+}
@@ -2,7 +2,10 @@ package influxql
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"strings"
 )
 
 // SelectBuilder represents a SELECT statement.
@@ -11,6 +14,7 @@ type SelectBuilder struct {
 	retention   Builder
 	fields      []Builder
 	where       []Builder
+	epoch       Precision
 	groupBy     []Builder
 	orderBy     []Builder
 	limit       int
@@ -30,7 +34,8 @@ func Select(fields ...interface{}) *SelectBuilder {
 }
 
 // From creates SELECT query with specified FROM with current retention policy.
-func From(measurement string) *SelectBuilder {
+// The measurement is either a name or a Regex.
+func From(measurement interface{}) *SelectBuilder {
 	s := &SelectBuilder{measurement: &literal{measurement}}
 	return s
 }
@@ -49,7 +54,8 @@ func Asc(field interface{}) *order {
 	}
 }
 
-// Fill represents FILL(x).
+// Fill represents FILL(x), x is nil, one of "null", "none", "previous" and
+// "linear" or a number.
 func (s *SelectBuilder) Fill(v interface{}) *SelectBuilder {
 	if v == nil {
 		s.fill = nullValue{}
@@ -67,8 +73,9 @@ func (s *SelectBuilder) Select(fields ...interface{}) *SelectBuilder {
 	return s
 }
 
-// From represents the FROM in SELECT x FROM.
-func (s *SelectBuilder) From(measurement string) *SelectBuilder {
+// From represents the FROM in SELECT x FROM, the measurement is either a name
+// or a Regex.
+func (s *SelectBuilder) From(measurement interface{}) *SelectBuilder {
 	s.measurement = &literal{measurement}
 	return s
 }
@@ -97,26 +104,42 @@ func (s *SelectBuilder) OrderBy(fields ...interface{}) *SelectBuilder {
 	return s
 }
 
-// Where replaces the current conditions.
-func (s *SelectBuilder) Where(expr string, values ...interface{}) *SelectBuilder {
+// Where replaces the current conditions. The expr is either a string with
+// optional values or a Condition.
+func (s *SelectBuilder) Where(expr interface{}, values ...interface{}) *SelectBuilder {
 	s.where = make([]Builder, 0, 1)
-	s.where = append(s.where, &Expr{expr: expr, values: values})
+	s.where = append(s.where, where(expr, values))
 	return s
 }
 
 // And adds a conjunction to the list of conditions.
-func (s *SelectBuilder) And(expr string, values ...interface{}) *SelectBuilder {
-	if len(s.where) > 0 {
-		s.where = append(s.where, andKeyword, &Expr{expr: expr, values: values})
-	} else {
-		s.where = append(s.where, &Expr{expr: expr, values: values})
-	}
+func (s *SelectBuilder) And(expr interface{}, values ...interface{}) *SelectBuilder {
+	s.where = appendConjunction(s.where, where(expr, values))
 	return s
 }
 
 // Or adds a disjunction to the list of conditions.
-func (s *SelectBuilder) Or(expr string, values ...interface{}) *SelectBuilder {
-	s.where = append(s.where, orKeyword, &Expr{expr: expr, values: values})
+func (s *SelectBuilder) Or(expr interface{}, values ...interface{}) *SelectBuilder {
+	s.where = appendDisjunction(s.where, where(expr, values))
+	return s
+}
+
+// AndGroup adds a parenthesized group of conditions joined with AND.
+func (s *SelectBuilder) AndGroup(fn func(*ConditionGroup)) *SelectBuilder {
+	s.where = appendConjunction(s.where, newConditionGroup(fn))
+	return s
+}
+
+// OrGroup adds a parenthesized group of conditions joined with OR.
+func (s *SelectBuilder) OrGroup(fn func(*ConditionGroup)) *SelectBuilder {
+	s.where = appendDisjunction(s.where, newConditionGroup(fn))
+	return s
+}
+
+// Epoch renders times in conditions as epoch integers with the given
+// precision instead of RFC3339 strings.
+func (s *SelectBuilder) Epoch(precision Precision) *SelectBuilder {
+	s.epoch = precision
 	return s
 }
 
@@ -146,37 +169,52 @@ func (s *SelectBuilder) SLimit(slimit int) *SelectBuilder {
 
 // Build satisfies Builder.
 func (s *SelectBuilder) Build() (string, error) {
+	return s.buildWith(&buildContext{epoch: s.epoch})
+}
+
+// BuildParams satisfies ParamsBuilder.
+func (s *SelectBuilder) BuildParams() (string, map[string]interface{}, error) {
+	ctx := &buildContext{epoch: s.epoch, params: map[string]interface{}{}}
+	q, err := s.buildWith(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	return q, ctx.params, nil
+}
+
+func (s *SelectBuilder) buildWith(ctx *buildContext) (string, error) {
 	data := selectTemplateValues{}
 
-	if err := compileInto(s.measurement, &data.Measurement); err != nil {
+	if err := compileInto(ctx, s.measurement, &data.Measurement); err != nil {
 		return "", err
 	}
 
-	if err := compileArrayInto(s.fields, &data.Fields); err != nil {
+	if err := validateSelect(s); err != nil {
 		return "", err
 	}
 
-	if err := compileArrayInto(s.where, &data.Where); err != nil {
+	if err := compileArrayInto(ctx, s.fields, &data.Fields); err != nil {
 		return "", err
 	}
 
-	if err := compileArrayInto(s.groupBy, &data.GroupBy); err != nil {
+	if err := compileConditionsInto(ctx, s.where, &data.Where); err != nil {
 		return "", err
 	}
 
-	if err := compileArrayInto(s.orderBy, &data.OrderBy); err != nil {
+	if err := compileArrayInto(ctx, s.groupBy, &data.GroupBy); err != nil {
+		return "", err
+	}
+
+	if err := compileArrayInto(ctx, s.orderBy, &data.OrderBy); err != nil {
 		return "", err
 	}
 
 	if s.fill != nil {
-		switch v := s.fill.(type) {
-		case nullValue:
-			data.Fill = "null"
-		case string:
-			data.Fill = fmt.Sprintf("%v", v)
-		default:
-			data.Fill = fmt.Sprintf("%v", v)
+		fill, err := fillOption(s.fill)
+		if err != nil {
+			return "", err
 		}
+		data.Fill = fill
 	}
 
 	var err error
@@ -200,3 +238,30 @@ func (s *SelectBuilder) Build() (string, error) {
 	}
 	return buf.String(), nil
 }
+
+// fillOption renders the argument of fill().
+func fillOption(v interface{}) (string, error) {
+	switch t := v.(type) {
+	case nullValue:
+		return "null", nil
+	case string:
+		switch strings.ToLower(t) {
+		case "null", "none", "previous", "linear":
+			return strings.ToLower(t), nil
+		}
+		if reDecimal.MatchString(t) {
+			return t, nil
+		}
+		return "", fmt.Errorf("Invalid fill option %q.", t)
+	case json.Number:
+		return (&value{t}).literal(RFC3339)
+	}
+
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return (&value{v}).literal(RFC3339)
+	}
+	return "", fmt.Errorf("Invalid fill option of type %T.", v)
+}
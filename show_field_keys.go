@@ -16,8 +16,9 @@ func ShowFieldKeys() *ShowFieldKeysBuilder {
 	return &ShowFieldKeysBuilder{}
 }
 
-// From represents the FROM in SHOW x FROM.
-func (s *ShowFieldKeysBuilder) From(measurement string) *ShowFieldKeysBuilder {
+// From represents the FROM in SHOW x FROM, the measurement is either a name
+// or a Regex.
+func (s *ShowFieldKeysBuilder) From(measurement interface{}) *ShowFieldKeysBuilder {
 	s.measurement = &literal{measurement}
 	return s
 }
@@ -30,16 +31,30 @@ func (s *ShowFieldKeysBuilder) RetentionPolicy(rp string) *ShowFieldKeysBuilder
 
 // Build satisfies Builder.
 func (s *ShowFieldKeysBuilder) Build() (string, error) {
+	return s.buildWith(&buildContext{})
+}
+
+// BuildParams satisfies ParamsBuilder.
+func (s *ShowFieldKeysBuilder) BuildParams() (string, map[string]interface{}, error) {
+	ctx := &buildContext{params: map[string]interface{}{}}
+	q, err := s.buildWith(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	return q, ctx.params, nil
+}
+
+func (s *ShowFieldKeysBuilder) buildWith(ctx *buildContext) (string, error) {
 	data := showFieldKeysTemplateValues{}
 
 	if s.measurement != nil {
-		if err := compileInto(s.measurement, &data.Measurement); err != nil {
+		if err := compileInto(ctx, s.measurement, &data.Measurement); err != nil {
 			return "", err
 		}
 	}
 
 	if s.rp != nil {
-		if err := compileInto(s.rp, &data.RetentionPolicy); err != nil {
+		if err := compileInto(ctx, s.rp, &data.RetentionPolicy); err != nil {
 			return "", err
 		}
 
@@ -6,6 +6,9 @@ import (
 
 // ShowMeasurements represents a SHOW MEASUREMENTS statement.
 type ShowMeasurementsBuilder struct {
+	measurement Builder
+	where       []Builder
+	epoch       Precision
 }
 
 // ShowMeasurements creates a SHOW query.
@@ -13,10 +16,68 @@ func ShowMeasurements() *ShowMeasurementsBuilder {
 	return &ShowMeasurementsBuilder{}
 }
 
+// WithMeasurement represents WITH MEASUREMENT, the measurement is either a
+// name or a Regex.
+func (s *ShowMeasurementsBuilder) WithMeasurement(measurement interface{}) *ShowMeasurementsBuilder {
+	s.measurement = &withClause{v: measurement}
+	return s
+}
+
+// Epoch renders times in conditions as epoch integers with the given
+// precision instead of RFC3339 strings.
+func (s *ShowMeasurementsBuilder) Epoch(precision Precision) *ShowMeasurementsBuilder {
+	s.epoch = precision
+	return s
+}
+
+// Where replaces the current conditions. The expr is either a string with
+// optional values or a Condition.
+func (s *ShowMeasurementsBuilder) Where(expr interface{}, values ...interface{}) *ShowMeasurementsBuilder {
+	s.where = make([]Builder, 0, 1)
+	s.where = append(s.where, where(expr, values))
+	return s
+}
+
+// And adds a conjunction to the list of conditions.
+func (s *ShowMeasurementsBuilder) And(expr interface{}, values ...interface{}) *ShowMeasurementsBuilder {
+	s.where = appendConjunction(s.where, where(expr, values))
+	return s
+}
+
+// Or adds a disjunction to the list of conditions.
+func (s *ShowMeasurementsBuilder) Or(expr interface{}, values ...interface{}) *ShowMeasurementsBuilder {
+	s.where = appendDisjunction(s.where, where(expr, values))
+	return s
+}
+
 // Build satisfies Builder.
 func (s *ShowMeasurementsBuilder) Build() (string, error) {
+	return s.buildWith(&buildContext{epoch: s.epoch})
+}
+
+// BuildParams satisfies ParamsBuilder.
+func (s *ShowMeasurementsBuilder) BuildParams() (string, map[string]interface{}, error) {
+	ctx := &buildContext{epoch: s.epoch, params: map[string]interface{}{}}
+	q, err := s.buildWith(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	return q, ctx.params, nil
+}
+
+func (s *ShowMeasurementsBuilder) buildWith(ctx *buildContext) (string, error) {
 	data := showMeasurementsTemplateValues{}
 
+	if s.measurement != nil {
+		if err := compileInto(ctx, s.measurement, &data.WithMeasurement); err != nil {
+			return "", err
+		}
+	}
+
+	if err := compileConditionsInto(ctx, s.where, &data.Where); err != nil {
+		return "", err
+	}
+
 	buf := bytes.NewBuffer(nil)
 	err := showMeasurementsTemplate.Execute(buf, data)
 	if err != nil {
@@ -15,6 +15,20 @@ func ShowRetentionPolicies() *ShowRetentionPoliciesBuilder {
 
 // Build satisfies Builder.
 func (s *ShowRetentionPoliciesBuilder) Build() (string, error) {
+	return s.buildWith(&buildContext{})
+}
+
+// BuildParams satisfies ParamsBuilder.
+func (s *ShowRetentionPoliciesBuilder) BuildParams() (string, map[string]interface{}, error) {
+	ctx := &buildContext{params: map[string]interface{}{}}
+	q, err := s.buildWith(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	return q, ctx.params, nil
+}
+
+func (s *ShowRetentionPoliciesBuilder) buildWith(ctx *buildContext) (string, error) {
 	data := showRetentionPoliciesTemplateValues{}
 
 	buf := bytes.NewBuffer(nil)
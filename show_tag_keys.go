@@ -9,6 +9,9 @@ import (
 type ShowTagKeysBuilder struct {
 	measurement Builder
 	rp          Builder
+	key         Builder
+	where       []Builder
+	epoch       Precision
 }
 
 // ShowTagKeys creates a SHOW query.
@@ -16,8 +19,9 @@ func ShowTagKeys() *ShowTagKeysBuilder {
 	return &ShowTagKeysBuilder{}
 }
 
-// From represents the FROM in SHOW x FROM.
-func (s *ShowTagKeysBuilder) From(measurement string) *ShowTagKeysBuilder {
+// From represents the FROM in SHOW x FROM, the measurement is either a name
+// or a Regex.
+func (s *ShowTagKeysBuilder) From(measurement interface{}) *ShowTagKeysBuilder {
 	s.measurement = &literal{measurement}
 	return s
 }
@@ -28,18 +32,66 @@ func (s *ShowTagKeysBuilder) RetentionPolicy(rp string) *ShowTagKeysBuilder {
 	return s
 }
 
+// WithKey represents WITH KEY, the key is either a tag key, a Regex or a list
+// of tag keys as []string.
+func (s *ShowTagKeysBuilder) WithKey(key interface{}) *ShowTagKeysBuilder {
+	s.key = &withClause{v: key, allowList: true}
+	return s
+}
+
+// Epoch renders times in conditions as epoch integers with the given
+// precision instead of RFC3339 strings.
+func (s *ShowTagKeysBuilder) Epoch(precision Precision) *ShowTagKeysBuilder {
+	s.epoch = precision
+	return s
+}
+
+// Where replaces the current conditions. The expr is either a string with
+// optional values or a Condition.
+func (s *ShowTagKeysBuilder) Where(expr interface{}, values ...interface{}) *ShowTagKeysBuilder {
+	s.where = make([]Builder, 0, 1)
+	s.where = append(s.where, where(expr, values))
+	return s
+}
+
+// And adds a conjunction to the list of conditions.
+func (s *ShowTagKeysBuilder) And(expr interface{}, values ...interface{}) *ShowTagKeysBuilder {
+	s.where = appendConjunction(s.where, where(expr, values))
+	return s
+}
+
+// Or adds a disjunction to the list of conditions.
+func (s *ShowTagKeysBuilder) Or(expr interface{}, values ...interface{}) *ShowTagKeysBuilder {
+	s.where = appendDisjunction(s.where, where(expr, values))
+	return s
+}
+
 // Build satisfies Builder.
 func (s *ShowTagKeysBuilder) Build() (string, error) {
+	return s.buildWith(&buildContext{epoch: s.epoch})
+}
+
+// BuildParams satisfies ParamsBuilder.
+func (s *ShowTagKeysBuilder) BuildParams() (string, map[string]interface{}, error) {
+	ctx := &buildContext{epoch: s.epoch, params: map[string]interface{}{}}
+	q, err := s.buildWith(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	return q, ctx.params, nil
+}
+
+func (s *ShowTagKeysBuilder) buildWith(ctx *buildContext) (string, error) {
 	data := showTagKeysTemplateValues{}
 
 	if s.measurement != nil {
-		if err := compileInto(s.measurement, &data.Measurement); err != nil {
+		if err := compileInto(ctx, s.measurement, &data.Measurement); err != nil {
 			return "", err
 		}
 	}
 
 	if s.rp != nil {
-		if err := compileInto(s.rp, &data.RetentionPolicy); err != nil {
+		if err := compileInto(ctx, s.rp, &data.RetentionPolicy); err != nil {
 			return "", err
 		}
 
@@ -50,6 +102,16 @@ func (s *ShowTagKeysBuilder) Build() (string, error) {
 		}
 	}
 
+	if s.key != nil {
+		if err := compileInto(ctx, s.key, &data.WithKey); err != nil {
+			return "", err
+		}
+	}
+
+	if err := compileConditionsInto(ctx, s.where, &data.Where); err != nil {
+		return "", err
+	}
+
 	buf := bytes.NewBuffer(nil)
 	err := showTagKeysTemplate.Execute(buf, data)
 	if err != nil {
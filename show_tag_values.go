@@ -0,0 +1,123 @@
+package influxql
+
+import (
+	"bytes"
+	"errors"
+)
+
+// ShowTagValuesBuilder represents a SHOW TAG VALUES statement.
+type ShowTagValuesBuilder struct {
+	measurement Builder
+	rp          Builder
+	key         Builder
+	where       []Builder
+	epoch       Precision
+}
+
+// ShowTagValues creates a SHOW query, WithKey must be specified.
+func ShowTagValues() *ShowTagValuesBuilder {
+	return &ShowTagValuesBuilder{}
+}
+
+// From represents the FROM in SHOW x FROM, the measurement is either a name
+// or a Regex.
+func (s *ShowTagValuesBuilder) From(measurement interface{}) *ShowTagValuesBuilder {
+	s.measurement = &literal{measurement}
+	return s
+}
+
+// RetentionPolicy represents a retention policy part of FROM statement.
+func (s *ShowTagValuesBuilder) RetentionPolicy(rp string) *ShowTagValuesBuilder {
+	s.rp = &literal{rp}
+	return s
+}
+
+// WithKey represents WITH KEY, the key is either a tag key, a Regex or a list
+// of tag keys as []string.
+func (s *ShowTagValuesBuilder) WithKey(key interface{}) *ShowTagValuesBuilder {
+	s.key = &withClause{v: key, allowList: true}
+	return s
+}
+
+// Epoch renders times in conditions as epoch integers with the given
+// precision instead of RFC3339 strings.
+func (s *ShowTagValuesBuilder) Epoch(precision Precision) *ShowTagValuesBuilder {
+	s.epoch = precision
+	return s
+}
+
+// Where replaces the current conditions. The expr is either a string with
+// optional values or a Condition.
+func (s *ShowTagValuesBuilder) Where(expr interface{}, values ...interface{}) *ShowTagValuesBuilder {
+	s.where = make([]Builder, 0, 1)
+	s.where = append(s.where, where(expr, values))
+	return s
+}
+
+// And adds a conjunction to the list of conditions.
+func (s *ShowTagValuesBuilder) And(expr interface{}, values ...interface{}) *ShowTagValuesBuilder {
+	s.where = appendConjunction(s.where, where(expr, values))
+	return s
+}
+
+// Or adds a disjunction to the list of conditions.
+func (s *ShowTagValuesBuilder) Or(expr interface{}, values ...interface{}) *ShowTagValuesBuilder {
+	s.where = appendDisjunction(s.where, where(expr, values))
+	return s
+}
+
+// Build satisfies Builder.
+func (s *ShowTagValuesBuilder) Build() (string, error) {
+	return s.buildWith(&buildContext{epoch: s.epoch})
+}
+
+// BuildParams satisfies ParamsBuilder.
+func (s *ShowTagValuesBuilder) BuildParams() (string, map[string]interface{}, error) {
+	ctx := &buildContext{epoch: s.epoch, params: map[string]interface{}{}}
+	q, err := s.buildWith(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	return q, ctx.params, nil
+}
+
+func (s *ShowTagValuesBuilder) buildWith(ctx *buildContext) (string, error) {
+	data := showTagValuesTemplateValues{}
+
+	if s.measurement != nil {
+		if err := compileInto(ctx, s.measurement, &data.Measurement); err != nil {
+			return "", err
+		}
+	}
+
+	if s.rp != nil {
+		if err := compileInto(ctx, s.rp, &data.RetentionPolicy); err != nil {
+			return "", err
+		}
+
+		if s.measurement == nil {
+			return "", errors.New(
+				"retention policy specified, but measurement was not specified",
+			)
+		}
+	}
+
+	if s.key == nil {
+		return "", errors.New("tag key was not specified")
+	}
+
+	if err := compileInto(ctx, s.key, &data.WithKey); err != nil {
+		return "", err
+	}
+
+	if err := compileConditionsInto(ctx, s.where, &data.Where); err != nil {
+		return "", err
+	}
+
+	buf := bytes.NewBuffer(nil)
+	err := showTagValuesTemplate.Execute(buf, data)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,238 @@
+package influxql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+)
+
+type streamedSeries struct {
+	statementID int
+	series      *Series
+	continued   bool
+}
+
+// SeriesIterator reads a /query response incrementally and yields its series
+// one at a time. The parts of a series split across the chunks of a chunked
+// response are joined, so the rows of the current series are kept in memory
+// until it is complete. MaxRows bounds them: a longer series is yielded in
+// parts of at most that many rows, Partial is set on every part but the last
+// one and Continued tells that a part carries the next rows of the previous
+// one.
+//
+//	it, err := client.Stream(ctx, query)
+//	if err != nil {
+//		return err
+//	}
+//	defer it.Close()
+//	for it.Next() {
+//		series := it.Series()
+//		...
+//	}
+//	return it.Err()
+type SeriesIterator struct {
+	ctx     context.Context
+	body    io.ReadCloser
+	decoder *json.Decoder
+	epoch   Precision
+	maxRows int
+
+	// pending is a partial series waiting for its continuation.
+	pending *streamedSeries
+	queue   []*streamedSeries
+	current *streamedSeries
+
+	done bool
+	err  error
+}
+
+// NewSeriesIterator creates an iterator over the JSON /query response read
+// from body, epoch is the precision the query was sent with.
+func NewSeriesIterator(
+	ctx context.Context, body io.ReadCloser, epoch Precision,
+) *SeriesIterator {
+	decoder := json.NewDecoder(body)
+	decoder.UseNumber()
+
+	return &SeriesIterator{
+		ctx:     ctx,
+		body:    body,
+		decoder: decoder,
+		epoch:   epoch,
+	}
+}
+
+// MaxRows sets the largest number of rows yielded at once, zero means that
+// series are always yielded whole. It is set before the first call to Next.
+func (it *SeriesIterator) MaxRows(rows int) *SeriesIterator {
+	it.maxRows = rows
+	return it
+}
+
+// Next advances to the next series or part of a series. It returns false at
+// the end of the response or when an error occurs, see Err.
+func (it *SeriesIterator) Next() bool {
+	it.current = nil
+	for len(it.queue) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+		it.read()
+	}
+
+	it.current = it.queue[0]
+	it.queue[0] = nil
+	it.queue = it.queue[1:]
+	return true
+}
+
+// Series returns the current series or part of a series.
+func (it *SeriesIterator) Series() *Series {
+	if it.current == nil {
+		return nil
+	}
+	return it.current.series
+}
+
+// StatementID returns the id of the statement the current series belongs to.
+func (it *SeriesIterator) StatementID() int {
+	if it.current == nil {
+		return 0
+	}
+	return it.current.statementID
+}
+
+// Continued tells whether the current series continues the previous one, it
+// is a part of a series longer than MaxRows.
+func (it *SeriesIterator) Continued() bool {
+	return it.current != nil && it.current.continued
+}
+
+// Err returns the error that stopped the iteration, including the errors
+// reported by the server for a statement and the cancellation of the
+// context.
+func (it *SeriesIterator) Err() error {
+	return it.err
+}
+
+// Close releases the response body, it must be called even when the
+// iteration is not complete.
+func (it *SeriesIterator) Close() error {
+	it.done = true
+	return it.body.Close()
+}
+
+// read decodes the next chunk of the response and queues the series that
+// are complete or long enough.
+func (it *SeriesIterator) read() {
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		return
+	}
+
+	chunk, err := decodeChunk(it.decoder, it.epoch)
+	if err == io.EOF {
+		it.done = true
+		it.flush()
+		return
+	}
+	if err != nil {
+		if ctxErr := it.ctx.Err(); ctxErr != nil {
+			err = ctxErr
+		}
+		it.err = err
+		return
+	}
+
+	for _, result := range chunk.Results {
+		for _, series := range result.Series {
+			it.add(result.StatementID, series)
+		}
+		if err := result.Error(); err != nil {
+			it.flush()
+			it.err = err
+			return
+		}
+	}
+
+	if chunk.Err != "" {
+		it.flush()
+		it.err = chunk.Error()
+	}
+}
+
+func (it *SeriesIterator) add(statementID int, series *Series) {
+	if it.pending != nil && it.pending.statementID == statementID &&
+		it.pending.series.continuedBy(series) {
+		it.pending.series.join(series)
+	} else {
+		it.flush()
+		it.pending = &streamedSeries{statementID: statementID, series: series}
+	}
+
+	it.split()
+	if !it.pending.series.Partial {
+		it.flush()
+	}
+}
+
+// split queues parts of MaxRows rows of the pending series, as long as rows
+// are left for its last part.
+func (it *SeriesIterator) split() {
+	if it.maxRows <= 0 {
+		return
+	}
+
+	for {
+		p := it.pending
+		rows := len(p.series.Values)
+		if rows < it.maxRows || rows == it.maxRows && !p.series.Partial {
+			return
+		}
+
+		part := *p.series
+		part.Values = p.series.Values[:it.maxRows:it.maxRows]
+		part.Partial = true
+		it.queue = append(it.queue, &streamedSeries{
+			statementID: p.statementID,
+			series:      &part,
+			continued:   p.continued,
+		})
+
+		// The rest is copied, so the rows of the part are not kept alive
+		// by the pending series.
+		rest := *p.series
+		rest.Values = append([][]interface{}(nil), p.series.Values[it.maxRows:]...)
+		it.pending = &streamedSeries{
+			statementID: p.statementID,
+			series:      &rest,
+			continued:   true,
+		}
+	}
+}
+
+// flush queues the pending series, unless it is the empty rest of a split
+// series that was not continued.
+func (it *SeriesIterator) flush() {
+	if it.pending == nil {
+		return
+	}
+	if !it.pending.continued || len(it.pending.series.Values) > 0 {
+		it.queue = append(it.queue, it.pending)
+	}
+	it.pending = nil
+}
+
+// Stream executes the statement built by b with chunking enabled and returns
+// an iterator over the series of the response.
+func (c *Client) Stream(ctx context.Context, b Builder) (*SeriesIterator, error) {
+	chunked := *c
+	chunked.chunked = true
+
+	resp, err := chunked.exec(ctx, b, string(JSON))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSeriesIterator(ctx, resp.Body, c.epoch), nil
+}
@@ -8,8 +8,6 @@ import (
 	"time"
 )
 
-const placeholder = "?"
-
 type nullValue struct{}
 
 var (
@@ -45,11 +43,31 @@ const showTagKeysTemplateText = `
 	{{- if or .Measurement .RetentionPolicy}} FROM {{end}}
 	{{- with .RetentionPolicy}}{{.}}.{{end}}
 	{{- with .Measurement }}{{.}}{{end}}
+	{{- with .WithKey}} WITH KEY {{.}}{{end}}
+	{{- with .Where}} WHERE {{joinWithSpace .}}{{end}}
 `
 
 type showTagKeysTemplateValues struct {
 	Measurement     string
 	RetentionPolicy string
+	WithKey         string
+	Where           []string
+}
+
+const showTagValuesTemplateText = `
+	SHOW TAG VALUES
+	{{- if or .Measurement .RetentionPolicy}} FROM {{end}}
+	{{- with .RetentionPolicy}}{{.}}.{{end}}
+	{{- with .Measurement }}{{.}}{{end}}
+	{{- with .WithKey}} WITH KEY {{.}}{{end}}
+	{{- with .Where}} WHERE {{joinWithSpace .}}{{end}}
+`
+
+type showTagValuesTemplateValues struct {
+	Measurement     string
+	RetentionPolicy string
+	WithKey         string
+	Where           []string
 }
 
 const createDatabaseTemplateText = `
@@ -60,11 +78,27 @@ type createDatabaseTemplateValues struct {
 	Database string
 }
 
+const createUserTemplateText = `
+	CREATE USER {{.Name}} WITH PASSWORD {{.Password}}
+	{{- if .IsAdmin}} WITH ALL PRIVILEGES{{end}}
+`
+
+type createUserTemplateValues struct {
+	Name     string
+	Password string
+	IsAdmin  bool
+}
+
 const showMeasurementsTemplateText = `
 	SHOW MEASUREMENTS
+	{{- with .WithMeasurement}} WITH MEASUREMENT {{.}}{{end}}
+	{{- with .Where}} WHERE {{joinWithSpace .}}{{end}}
 `
 
-type showMeasurementsTemplateValues struct{}
+type showMeasurementsTemplateValues struct {
+	WithMeasurement string
+	Where           []string
+}
 
 const showRetentionPoliciesTemplateText = `
 	SHOW RETENTION POLICIES
@@ -185,6 +219,11 @@ var showTagKeysTemplate = template.Must(
 		Parse(cleanTemplate(showTagKeysTemplateText)),
 )
 
+var showTagValuesTemplate = template.Must(
+	template.New("showTagValues").Funcs(templateFuncs).
+		Parse(cleanTemplate(showTagValuesTemplateText)),
+)
+
 var showMeasurementsTemplate = template.Must(
 	template.New("showMeasurements").Funcs(templateFuncs).
 		Parse(cleanTemplate(showMeasurementsTemplateText)),
@@ -195,6 +234,11 @@ var createDatabaseTemplate = template.Must(
 		Parse(cleanTemplate(createDatabaseTemplateText)),
 )
 
+var createUserTemplate = template.Must(
+	template.New("createUser").Funcs(templateFuncs).
+		Parse(cleanTemplate(createUserTemplateText)),
+)
+
 var showRetentionPoliciesTemplate = template.Must(
 	template.New("showRetentionPolicies").Funcs(templateFuncs).
 		Parse(cleanTemplate(showRetentionPoliciesTemplateText)),
@@ -214,7 +258,11 @@ type order struct {
 }
 
 func (order *order) Build() (string, error) {
-	field, err := order.field.Build()
+	return order.buildWith(&buildContext{})
+}
+
+func (order *order) buildWith(ctx *buildContext) (string, error) {
+	field, err := order.field.buildWith(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -222,82 +270,49 @@ func (order *order) Build() (string, error) {
 	return field + " " + strings.ToUpper(order.order), nil
 }
 
-// Expr represents an expression.
-type Expr struct {
-	expr   string
-	values []interface{}
+var stringLiteralEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`'`, `\'`,
+	"\n", `\n`,
+)
+
+// quoteString returns s as an InfluxQL string literal.
+func quoteString(s string) string {
+	return "'" + stringLiteralEscaper.Replace(s) + "'"
 }
 
-// Build satisfies Builder.
-func (e *Expr) Build() (string, error) {
-	placeholders := strings.Count(e.expr, placeholder)
-
-	if placeholders > 0 {
-		// Where("foo = ?", "bar")
-		if placeholders != len(e.values) {
-			return "", fmt.Errorf(
-				"Mismatched number of placeholders (%d) and values (%d)",
-				strings.Count(e.expr, placeholder),
-				len(e.values),
-			)
-		}
-	} else {
-		if len(e.values) > 0 {
-			parts := strings.Split(strings.TrimSpace(reWhiteChars.ReplaceAllString(e.expr, " ")), " ")
-			lparts := len(parts)
-
-			if lparts < 1 {
-				return "", fmt.Errorf("Expecting statement.")
-			} else if lparts < 2 {
-				// Where("foo", "bar")
-				if len(e.values) != 1 {
-					return "", fmt.Errorf("Expecting exactly one value.")
-				}
-				e.expr = fmt.Sprintf("%q = ?", parts[0])
-			} else if lparts < 3 {
-				// Where("foo =", "bar")
-				if len(e.values) != 1 {
-					return "", fmt.Errorf("Expecting exactly one value.")
-				}
-				e.expr = fmt.Sprintf("%q %s ?", parts[0], parts[1])
-			} else {
-				return "", fmt.Errorf("Unsupported expression %q", e.expr)
-			}
-		}
-	}
+var identEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+)
 
-	compiled := make([]interface{}, 0, len(e.values))
-	for i := range e.values {
-		lit := &value{e.values[i]}
-		c, err := lit.Build()
-		if err != nil {
-			return "", err
-		}
-		compiled = append(compiled, c)
-	}
+// quoteIdent returns s as a double quoted InfluxQL identifier.
+func quoteIdent(s string) string {
+	return `"` + identEscaper.Replace(s) + `"`
+}
+
+// Ident represents an identifier, like a measurement, field or tag key. It is
+// always quoted, so keywords, dots, quotes and unicode are safe to use.
+type Ident string
 
-	s := strings.Replace(e.expr, "?", "%s", -1)
-	return fmt.Sprintf(s, compiled...), nil
+// Build satisfies Builder.
+func (i Ident) Build() (string, error) {
+	return quoteIdent(string(i)), nil
 }
 
-type value struct {
-	v interface{}
+type raw string
+
+// Build satisfies Builder.
+func (r raw) Build() (string, error) {
+	return string(r), nil
 }
 
-func (v *value) Build() (string, error) {
-	switch t := v.v.(type) {
-	case string:
-		return fmt.Sprintf(`'%s'`, t), nil
-	case int, uint, int64, uint64, int32, uint32, int8, uint8:
-		return fmt.Sprintf("%d", t), nil
-	case time.Time:
-		return fmt.Sprintf(`'%s'`, t.Format("2006-01-02T15:04:05Z")), nil
-	case time.Duration:
-		return timeFormat(t), nil
-	default:
-		return fmt.Sprintf(`'%v'`, t), nil
-	}
-	panic("reached")
+// Raw represents text that is inserted into the query as is. It is an escape
+// hatch for syntax this package does not support, never pass user input to
+// it.
+func Raw(s string) Builder {
+	return raw(s)
 }
 
 type literal struct {
@@ -305,37 +320,36 @@ type literal struct {
 }
 
 func (l *literal) Build() (string, error) {
+	return l.buildWith(&buildContext{})
+}
+
+func (l *literal) buildWith(ctx *buildContext) (string, error) {
 	switch v := l.v.(type) {
 	case Builder:
-		return v.Build()
+		return ctx.build(v)
 	case time.Duration:
 		t := Time(v)
 		return t.Build()
 	case string:
-		if strings.ContainsAny(v, `".`) {
-			return fmt.Sprintf(`%s`, v), nil
-		}
-
 		if v == "*" {
 			return v, nil
 		}
 
-		return fmt.Sprintf(`%q`, v), nil
+		return quoteIdent(v), nil
 	default:
-		return fmt.Sprintf(`"%v"`, v), nil
+		return quoteIdent(fmt.Sprintf("%v", v)), nil
 	}
-	panic("reached")
 }
 
-func compileInto(src Builder, dst *string) (err error) {
-	*dst, err = src.Build()
+func compileInto(ctx *buildContext, src Builder, dst *string) (err error) {
+	*dst, err = ctx.build(src)
 	return
 }
 
-func compileArrayInto(src []Builder, dst *[]string) error {
+func compileArrayInto(ctx *buildContext, src []Builder, dst *[]string) error {
 	v := make([]string, 0, len(src))
 	for i := range src {
-		s, err := src[i].Build()
+		s, err := ctx.build(src[i])
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,116 @@
+package influxql
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Precision is the unit of epoch timestamps.
+type Precision string
+
+// Epoch precisions.
+const (
+	// RFC3339 renders times as RFC3339 string literals with nanoseconds, it
+	// is the default.
+	RFC3339              Precision = ""
+	PrecisionNanosecond  Precision = "ns"
+	PrecisionMicrosecond Precision = "u"
+	PrecisionMillisecond Precision = "ms"
+	PrecisionSecond      Precision = "s"
+)
+
+var precisionUnits = map[Precision]time.Duration{
+	PrecisionNanosecond:  time.Nanosecond,
+	PrecisionMicrosecond: time.Microsecond,
+	PrecisionMillisecond: time.Millisecond,
+	PrecisionSecond:      time.Second,
+}
+
+// formatTime returns t as an InfluxQL time literal. Times are converted to UTC
+// and rendered either as an RFC3339 string with nanoseconds or as an epoch
+// integer with a precision suffix, in which case they are truncated to the
+// precision.
+func formatTime(t time.Time, precision Precision) (string, error) {
+	if precision == RFC3339 {
+		return quoteString(t.UTC().Format(time.RFC3339Nano)), nil
+	}
+
+	n, err := epoch(t, precision)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d%s", n, precision), nil
+}
+
+// epoch returns t as a number of precision units since the Unix epoch,
+// truncated towards the past. Times that do not fit in an int64, like times
+// outside of the years 1678-2262 in nanoseconds, are rejected.
+func epoch(t time.Time, precision Precision) (int64, error) {
+	unit, ok := precisionUnits[precision]
+	if !ok {
+		return 0, fmt.Errorf("Unsupported precision %q.", string(precision))
+	}
+
+	// Unix rounds towards the past and Nanosecond is never negative, the
+	// fraction is moved into the seconds towards zero so that neither the
+	// product nor the sum overflow before they are checked.
+	perSecond := int64(time.Second / unit)
+	sec := t.Unix()
+	frac := int64(t.Nanosecond()) / int64(unit)
+	if sec < 0 {
+		sec++
+		frac -= perSecond
+	}
+	if sec > math.MaxInt64/perSecond || sec < math.MinInt64/perSecond ||
+		(frac > 0 && sec*perSecond > math.MaxInt64-frac) ||
+		(frac < 0 && sec*perSecond < math.MinInt64-frac) {
+		return 0, fmt.Errorf(
+			"Time %s is out of the range of epochs in %s.",
+			t.UTC().Format(time.RFC3339Nano), string(precision),
+		)
+	}
+	return sec*perSecond + frac, nil
+}
+
+// NowExpr represents now() shifted by a duration.
+type NowExpr struct {
+	offset time.Duration
+}
+
+// Now represents the now() function, the current server time.
+func Now() *NowExpr {
+	return &NowExpr{}
+}
+
+// Add returns the expression shifted forward by d.
+func (n *NowExpr) Add(d time.Duration) *NowExpr {
+	return &NowExpr{offset: n.offset + d}
+}
+
+// Sub returns the expression shifted back by d.
+func (n *NowExpr) Sub(d time.Duration) *NowExpr {
+	return &NowExpr{offset: n.offset - d}
+}
+
+// Build satisfies Builder.
+func (n *NowExpr) Build() (string, error) {
+	switch {
+	case n.offset > 0:
+		return "now() + " + timeFormat(n.offset), nil
+	case n.offset < 0:
+		return "now() - " + timeFormat(-n.offset), nil
+	}
+	return "now()", nil
+}
+
+// TimeRange represents the half-open time range start <= time < end. The
+// bounds are either time.Time values or Now expressions.
+func TimeRange(start interface{}, end interface{}) Condition {
+	return And(Gte("time", start), Lt("time", end))
+}
+
+// Since represents time >= now() - d.
+func Since(d time.Duration) Condition {
+	return Gte("time", Now().Sub(d))
+}
@@ -0,0 +1,204 @@
+package influxql
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ValidationErrorKind identifies a semantic problem found in a query.
+type ValidationErrorKind int
+
+// Validation error kinds.
+const (
+	// MissingGroupByTime is reported when a function requires GROUP BY time().
+	MissingGroupByTime ValidationErrorKind = iota + 1
+	// MixedAggregates is reported when raw fields are selected together with
+	// aggregations.
+	MixedAggregates
+	// NestedAggregate is reported when an aggregation is applied to the
+	// result of another aggregation.
+	NestedAggregate
+)
+
+// ValidationError describes a semantic problem found in a query before it is
+// sent to the server.
+type ValidationError struct {
+	Kind ValidationErrorKind
+	// Field is the position of the offending field in the SELECT clause.
+	Field int
+	// Func is the name of the offending function, if any.
+	Func   string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Func != "" {
+		return fmt.Sprintf("Field %d: %s %s.", e.Field+1, e.Func, e.Reason)
+	}
+	return fmt.Sprintf("Field %d: %s.", e.Field+1, e.Reason)
+}
+
+// ValidationErrors is a list of semantic problems found in a query.
+type ValidationErrors []*ValidationError
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, " ")
+}
+
+// unwrapCall returns the function call wrapped by v, if any.
+func unwrapCall(v interface{}) *F {
+	switch t := v.(type) {
+	case *F:
+		return t
+	case *literal:
+		return unwrapCall(t.v)
+	}
+	return nil
+}
+
+// isAggregation tells whether the function reduces a series to fewer
+// points, this is true for aggregates and selectors.
+func isAggregation(f *F) bool {
+	spec, ok := LookupFunc(f.name)
+	if !ok {
+		return false
+	}
+	return spec.Kind == Aggregate || spec.Kind == Selector
+}
+
+// containsAggregation tells whether f or any function nested in it is an
+// aggregation.
+func containsAggregation(f *F) bool {
+	if isAggregation(f) {
+		return true
+	}
+	for _, arg := range f.args {
+		if call := unwrapCall(arg); call != nil && containsAggregation(call) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasGroupByTime tells whether the GROUP BY clause groups by time
+// intervals. Raw items are not parsed, they are trusted when they call
+// time().
+func hasGroupByTime(groupBy []Builder) bool {
+	for _, b := range groupBy {
+		l, ok := b.(*literal)
+		if !ok {
+			continue
+		}
+		switch v := l.v.(type) {
+		case *timeGroup, time.Duration:
+			return true
+		case raw:
+			if strings.Contains(strings.ToLower(string(v)), "time(") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type selectValidator struct {
+	groupByTime bool
+	errs        ValidationErrors
+}
+
+func (v *selectValidator) report(
+	kind ValidationErrorKind, field int, f *F, reason string,
+) {
+	err := &ValidationError{Kind: kind, Field: field, Reason: reason}
+	if f != nil {
+		err.Func = strings.ToUpper(f.name)
+	}
+	v.errs = append(v.errs, err)
+}
+
+func (v *selectValidator) walk(field int, f *F) {
+	spec, known := LookupFunc(f.name)
+
+	for _, arg := range f.args {
+		inner := unwrapCall(arg)
+		if inner == nil {
+			continue
+		}
+
+		if known {
+			if spec.GroupByTime == GroupByTimeWhenNested &&
+				!v.groupByTime && containsAggregation(inner) {
+				v.report(
+					MissingGroupByTime, field, f,
+					"applied to an aggregation requires GROUP BY time()",
+				)
+			}
+
+			if isAggregation(f) && isAggregation(inner) &&
+				!(spec.Name == "COUNT" && strings.EqualFold(inner.name, "DISTINCT")) {
+				v.report(
+					NestedAggregate, field, f,
+					fmt.Sprintf(
+						"cannot aggregate the result of %s",
+						strings.ToUpper(inner.name),
+					),
+				)
+			}
+		}
+
+		v.walk(field, inner)
+	}
+
+	if known && spec.GroupByTime == GroupByTimeRequired && !v.groupByTime {
+		v.report(MissingGroupByTime, field, f, "requires GROUP BY time()")
+	}
+}
+
+// validateSelect checks the semantics of the SELECT fields.
+func validateSelect(s *SelectBuilder) error {
+	v := &selectValidator{groupByTime: hasGroupByTime(s.groupBy)}
+
+	var (
+		raw          = -1
+		aggregations = 0
+		selectors    = 0
+	)
+
+	for i, field := range s.fields {
+		call := unwrapCall(field)
+		if call == nil {
+			if raw < 0 {
+				raw = i
+			}
+			continue
+		}
+
+		v.walk(i, call)
+
+		if containsAggregation(call) {
+			aggregations++
+			if spec, ok := LookupFunc(call.name); ok && spec.Kind == Selector {
+				selectors++
+			}
+		}
+	}
+
+	// A single selector may be combined with raw fields, it returns the
+	// fields of the selected point.
+	if raw >= 0 && aggregations > 0 && !(aggregations == 1 && selectors == 1) {
+		v.report(
+			MixedAggregates, raw, nil,
+			"raw fields cannot be mixed with aggregations",
+		)
+	}
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
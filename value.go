@@ -0,0 +1,115 @@
+package influxql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Valuer is implemented by types that choose their own InfluxQL
+// representation, like IDs, enums or decimals. The returned value is rendered
+// as any other value, for example return a string to get a string literal.
+type Valuer interface {
+	InfluxQLValue() (interface{}, error)
+}
+
+var reDecimal = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+type value struct {
+	v interface{}
+}
+
+func (v *value) Build() (string, error) {
+	return v.buildWith(&buildContext{})
+}
+
+func (v *value) buildWith(ctx *buildContext) (string, error) {
+	switch t := v.v.(type) {
+	case nil:
+		return "", fmt.Errorf("Unsupported nil value.")
+	case Valuer:
+		iv, err := t.InfluxQLValue()
+		if err != nil {
+			return "", err
+		}
+		if _, ok := iv.(Valuer); ok {
+			return "", fmt.Errorf("%T.InfluxQLValue returned a Valuer.", t)
+		}
+		return (&value{iv}).buildWith(ctx)
+	case Builder:
+		return ctx.build(t)
+	case time.Duration:
+		return timeFormat(t), nil
+	}
+
+	rv := reflect.ValueOf(v.v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return "", fmt.Errorf("Unsupported nil value.")
+		}
+		return (&value{rv.Elem().Interface()}).buildWith(ctx)
+	}
+
+	s, err := v.literal(ctx.epoch)
+	if err != nil {
+		return "", err
+	}
+	if ctx.params != nil {
+		return ctx.bind(v.v)
+	}
+	return s, nil
+}
+
+// literal renders a scalar value.
+func (v *value) literal(epoch Precision) (string, error) {
+	switch t := v.v.(type) {
+	case string:
+		return quoteString(t), nil
+	case bool:
+		return strconv.FormatBool(t), nil
+	case time.Time:
+		return formatTime(t, epoch)
+	case json.Number:
+		if reDecimal.MatchString(t.String()) {
+			return t.String(), nil
+		}
+		f, err := t.Float64()
+		if err != nil {
+			return "", fmt.Errorf("Invalid number %q.", t.String())
+		}
+		return formatFloat(f, 64)
+	}
+
+	rv := reflect.ValueOf(v.v)
+	switch rv.Kind() {
+	case reflect.String:
+		return quoteString(rv.String()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return formatFloat(rv.Float(), rv.Type().Bits())
+	}
+
+	if s, ok := v.v.(fmt.Stringer); ok {
+		return quoteString(s.String()), nil
+	}
+
+	return "", fmt.Errorf("Unsupported value type %T.", v.v)
+}
+
+// formatFloat writes f without an exponent, which InfluxQL does not support,
+// using the fewest digits that represent it exactly.
+func formatFloat(f float64, bits int) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("Unsupported float value %v.", f)
+	}
+	return strconv.FormatFloat(f, 'f', -1, bits), nil
+}
@@ -0,0 +1,41 @@
+package influxql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// withClause represents the condition of WITH KEY and WITH MEASUREMENT
+// clauses. A name is compared with =, a Regex is matched with =~ and a list of
+// names is rendered as IN (...) when lists are allowed.
+type withClause struct {
+	v         interface{}
+	allowList bool
+}
+
+// Build satisfies Builder.
+func (w *withClause) Build() (string, error) {
+	switch t := w.v.(type) {
+	case Regex:
+		s, err := t.Build()
+		if err != nil {
+			return "", err
+		}
+		return "=~ " + s, nil
+	case string:
+		return "= " + quoteIdent(t), nil
+	case []string:
+		if !w.allowList {
+			break
+		}
+		if len(t) == 0 {
+			return "", fmt.Errorf("Expecting at least one name.")
+		}
+		names := make([]string, 0, len(t))
+		for _, name := range t {
+			names = append(names, quoteIdent(name))
+		}
+		return "IN (" + strings.Join(names, ", ") + ")", nil
+	}
+	return "", fmt.Errorf("Unsupported WITH clause value type %T.", w.v)
+}
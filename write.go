@@ -0,0 +1,165 @@
+package influxql
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Consistency is the number of nodes that must confirm a write in an
+// InfluxDB Enterprise cluster.
+type Consistency string
+
+// Write consistency levels.
+const (
+	ConsistencyAny    Consistency = "any"
+	ConsistencyOne    Consistency = "one"
+	ConsistencyQuorum Consistency = "quorum"
+	ConsistencyAll    Consistency = "all"
+)
+
+// PartialWriteError is returned when the server accepted a write but dropped
+// some of its points, like points with a field type conflict or outside of
+// the retention policy. BatchWriter does not retry such writes, since the
+// accepted points are already stored.
+type PartialWriteError struct {
+	// Dropped is the number of dropped points, -1 when the server does not
+	// report it.
+	Dropped int
+	Message string
+}
+
+func (e *PartialWriteError) Error() string {
+	return "influxdb: " + e.Message
+}
+
+var droppedPattern = regexp.MustCompile(`dropped=(\d+)`)
+
+// Writer writes points to the /write endpoint of InfluxDB, it is created by
+// Client.Writer and shares its address, HTTP client and credentials.
+type Writer struct {
+	client *Client
+
+	database        string
+	retentionPolicy string
+	precision       Precision
+	consistency     Consistency
+}
+
+// Writer creates a writer of points to the given database.
+func (c *Client) Writer(database string) *Writer {
+	return &Writer{client: c, database: database}
+}
+
+// RetentionPolicy sets the retention policy points are written to, the
+// default policy of the database is used otherwise.
+func (w *Writer) RetentionPolicy(name string) *Writer {
+	w.retentionPolicy = name
+	return w
+}
+
+// Precision sets the precision of timestamps, nanoseconds by default.
+// Timestamps are truncated to it.
+func (w *Writer) Precision(precision Precision) *Writer {
+	w.precision = precision
+	return w
+}
+
+// Consistency sets the write consistency level.
+func (w *Writer) Consistency(consistency Consistency) *Writer {
+	w.consistency = consistency
+	return w
+}
+
+// encode returns the points in the line protocol.
+func (w *Writer) encode(points []*Point) ([]byte, error) {
+	var (
+		body []byte
+		err  error
+	)
+	for i, p := range points {
+		body, err = p.appendLine(body, w.precision)
+		if err != nil {
+			return nil, fmt.Errorf("Point %d: %v", i+1, err)
+		}
+		body = append(body, '\n')
+	}
+	return body, nil
+}
+
+// Write sends the points in a single request.
+func (w *Writer) Write(ctx context.Context, points ...*Point) error {
+	if len(points) == 0 {
+		return nil
+	}
+
+	body, err := w.encode(points)
+	if err != nil {
+		return err
+	}
+
+	return w.post(ctx, bytes.NewReader(body), "")
+}
+
+// post sends a line protocol body to /write.
+func (w *Writer) post(ctx context.Context, body io.Reader, encoding string) error {
+	values := url.Values{}
+	values.Set("db", w.database)
+	if w.retentionPolicy != "" {
+		values.Set("rp", w.retentionPolicy)
+	}
+	if w.precision != RFC3339 {
+		values.Set("precision", string(w.precision))
+	}
+	if w.consistency != "" {
+		values.Set("consistency", string(w.consistency))
+	}
+
+	c := w.client
+	req, err := http.NewRequest(
+		http.MethodPost, c.addr+"/write?"+values.Encode(), body,
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	c.authorize(req)
+
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return partialWrite(readServerError(resp))
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// partialWrite converts the 400 responses of partial writes to
+// PartialWriteError.
+func partialWrite(err error) error {
+	serverErr, ok := err.(*ServerError)
+	if !ok || serverErr.StatusCode != http.StatusBadRequest ||
+		!strings.Contains(serverErr.Message, "partial write") {
+		return err
+	}
+
+	dropped := -1
+	if m := droppedPattern.FindStringSubmatch(serverErr.Message); m != nil {
+		dropped, _ = strconv.Atoi(m[1])
+	}
+	return &PartialWriteError{Dropped: dropped, Message: serverErr.Message}
+}